@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -228,3 +229,367 @@ func TestFlagCombinations(t *testing.T) {
 		t.Errorf("Expected JSON output, got: %s", stdout)
 	}
 }
+
+func TestEditionFeatures(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT f.edition, fl.features->>'field_presence' AS presence FROM files f JOIN messages m ON m.file = f.name JOIN fields fl ON fl.message = m.full_name WHERE fl.id = 'example.editions.Account.display_name'",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(stdout, "2023,EXPLICIT") {
+		t.Errorf("Expected resolved EXPLICIT presence in edition 2023, got: %s", stdout)
+	}
+}
+
+func TestFieldSemanticsColumns(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT name, has_presence, is_packed, is_deprecated, is_group FROM fields WHERE message = 'example.legacy.LegacyRecord' ORDER BY number",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedRows := []string{
+		"id,true,false,false,false",
+		"note,true,false,true,false",
+		"samples,false,true,false,false",
+		"audit,true,false,false,true",
+	}
+	for _, expected := range expectedRows {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got: %s", expected, stdout)
+		}
+	}
+}
+
+func TestExtensionFields(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", `SELECT
+				(SELECT count(*) FROM fields WHERE message = 'google.protobuf.MethodOptions') AS own,
+				(SELECT count(*) FROM all_fields WHERE message = 'google.protobuf.MethodOptions' AND is_extension_field) AS extensions`,
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Extensions stay out of fields, and are listed under the message they
+	// extend in all_fields
+	if !strings.Contains(stdout, "0,3") {
+		t.Errorf("Expected no fields and 3 extensions, got: %s", stdout)
+	}
+}
+
+func TestDeprecatedMethods(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{"-q", "SELECT name FROM methods WHERE is_deprecated", "-f", "csv", "testdata"})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(stdout, "ListUsersLegacy") {
+		t.Errorf("Expected deprecated method ListUsersLegacy, got: %s", stdout)
+	}
+}
+
+func TestEnumAliases(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT name, is_alias_of FROM enum_values WHERE is_alias_of IS NOT NULL",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "PRIORITY_DEFAULT,example.legacy.Priority.PRIORITY_NORMAL"
+	if !strings.Contains(stdout, expected) {
+		t.Errorf("Expected output to contain %q, got: %s", expected, stdout)
+	}
+}
+
+func TestEnumRangesView(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT min_number, max_number, alias_count, gap_count, gaps FROM enum_ranges WHERE enum = 'example.legacy.Priority'",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "1,9,1,5,[3-4 6-8]"
+	if !strings.Contains(stdout, expected) {
+		t.Errorf("Expected output to contain %q, got: %s", expected, stdout)
+	}
+}
+
+func TestHTTPRules(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT r.verb, r.path_template, p.field_id FROM http_rules r JOIN http_path_params p USING (method, binding_index) WHERE r.method = 'example.library.LibraryService.UpdateBook'",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "PATCH,/v1/{book.name=shelves/*/books/*},example.library.Book.name"
+	if !strings.Contains(stdout, expected) {
+		t.Errorf("Expected output to contain %q, got: %s", expected, stdout)
+	}
+}
+
+func TestHTTPAdditionalBindings(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT binding_index, path_template FROM http_rules WHERE method = 'example.library.LibraryService.GetBook' ORDER BY binding_index",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"0,/v1/{name=shelves/*/books/*}", "1,/v1/books/{name}"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got: %s", expected, stdout)
+		}
+	}
+}
+
+func TestFieldConstraints(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT f.id FROM fields f WHERE f.message LIKE 'example.signup.%' AND f.type = 'string' AND NOT EXISTS (SELECT 1 FROM field_constraints c WHERE c.field_id = f.id AND c.rule = 'max_len') ORDER BY f.id",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(stdout, "example.signup.SignupRequest.password\n") {
+		t.Errorf("Expected password to lack max_len, got: %s", stdout)
+	}
+	for _, constrained := range []string{"SignupRequest.display_name", "LegacySignupRequest.nickname"} {
+		if strings.Contains(stdout, constrained) {
+			t.Errorf("Expected %s to have max_len, got: %s", constrained, stdout)
+		}
+	}
+}
+
+func TestMessageCELConstraints(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT message, rule, cel_expression FROM message_constraints WHERE rule_family = 'cel'",
+			"-f", "csv",
+			"testdata",
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "example.signup.SignupRequest,passwords_match,this.password == this.password_confirmation"
+	if !strings.Contains(stdout, expected) {
+		t.Errorf("Expected output to contain %q, got: %s", expected, stdout)
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	_, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"--timeout", "100ms",
+			"-q", "SELECT count(*) FROM range(10000000000) a, range(10) b",
+			"testdata",
+		})
+	})
+
+	if err == nil {
+		t.Fatal("Expected error for query exceeding timeout")
+	}
+
+	if !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Expected timeout error, got: %v", err)
+	}
+}
+
+func TestQueryFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checks.sql")
+	script := `-- Messages named User; the semicolon in this comment is not a separator
+CREATE VIEW named AS SELECT full_name FROM messages WHERE name = 'User' OR name LIKE '%;%';
+SELECT count(*) AS named_count FROM named;
+SELECT 'done' AS status;
+`
+	if err := os.WriteFile(file, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{"--file", file, "-f", "csv", "testdata"})
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "named_count\n3\n\nstatus\ndone\n"
+	if stdout != expected {
+		t.Errorf("Expected output %q, got: %q", expected, stdout)
+	}
+}
+
+func TestQueryStdin(t *testing.T) {
+	r, w, _ := os.Pipe()
+	w.WriteString("SELECT 1 AS a; SELECT 2 AS b;")
+	w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{"-q", "-", "-f", "csv", "testdata"})
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "a\n1\n\nb\n2\n"
+	if stdout != expected {
+		t.Errorf("Expected output %q, got: %q", expected, stdout)
+	}
+}
+
+func TestQueryStdinEmpty(t *testing.T) {
+	r, w, _ := os.Pipe()
+	w.WriteString("\n")
+	w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+
+	_, _, err := captureOutput(func() error {
+		return mainE([]string{"-q", "-", "testdata"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "no query read from stdin") {
+		t.Errorf("Expected an empty stdin error, got: %v", err)
+	}
+}
+
+func TestScriptError(t *testing.T) {
+	_, _, err := captureOutput(func() error {
+		return mainE([]string{"-q", "SELECT 1;\nSELECT * FROM no_such_table", "testdata"})
+	})
+
+	if err == nil {
+		t.Fatal("Expected error for failing statement")
+	}
+
+	if !strings.Contains(err.Error(), "statement 2 (line 2)") {
+		t.Errorf("Expected error to name the failing statement, got: %v", err)
+	}
+}
+
+func TestDuplicateInputs(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{"-q", "SELECT count(*) AS n FROM files WHERE name = 'users.proto'", "-f", "csv", "testdata", "testdata/users.proto"})
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if stdout != "n\n1\n" {
+		t.Errorf("Expected users.proto to be loaded once, got: %q", stdout)
+	}
+}
+
+func TestColumnComments(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{
+			"-q", "SELECT column_comment FROM information_schema.columns WHERE table_name = 'fields' AND column_name = 'json_name'",
+			"--format", "csv",
+			"testdata",
+		})
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if stdout != "COLUMN_COMMENT\nJSON name\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
+
+func TestHelpListsTables(t *testing.T) {
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{"--help"})
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"Tables available:", "fields               Message fields", "Views available:", "enum_ranges"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected help to contain %q", want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	for _, query := range []string{"DROP TABLE fields", "SELECT 1; DELETE FROM fields", "INSTALL httpfs"} {
+		_, _, err := captureOutput(func() error {
+			return mainE([]string{"--read-only", "-q", query, "testdata"})
+		})
+		if err == nil || !strings.Contains(err.Error(), "read-only mode: only SELECT statements are allowed") {
+			t.Errorf("Expected %q to be rejected, got: %v", query, err)
+		}
+	}
+
+	_, _, err := captureOutput(func() error {
+		return mainE([]string{"--read-only", "-q", "SELECT * FROM read_text('cli_test.go')", "testdata"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "file system operations are disabled") {
+		t.Errorf("Expected file access to be disabled, got: %v", err)
+	}
+
+	stdout, _, err := captureOutput(func() error {
+		return mainE([]string{"--read-only", "-q", "SELECT count(*) AS n FROM files WHERE name = 'users.proto'", "-f", "csv", "testdata"})
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout != "n\n1\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
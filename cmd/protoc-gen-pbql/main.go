@@ -0,0 +1,49 @@
+// Command protoc-gen-pbql is a protoc and buf plugin that runs pbql queries
+// over the files being generated. Each <file>=<query> entry in the plugin
+// parameter writes the query's results to that file, formatted by its
+// extension, and each lint=<query> entry fails generation with one error per
+// row the query returns:
+//
+//	protoc --pbql_out=. --pbql_opt='messages.csv=SELECT full_name FROM messages' api.proto
+//
+// Entries are separated by commas; a query of @path is read from a file.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/connor15mcc/pbql-go/codegen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	if err := run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "protoc-gen-pbql: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading request: %v", err)
+	}
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("error decoding request: %v", err)
+	}
+
+	resp := codegen.Generate(context.Background(), req)
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("error writing response: %v", err)
+	}
+	return nil
+}
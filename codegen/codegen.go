@@ -0,0 +1,226 @@
+// Package codegen runs pbql queries as a protoc plugin, writing their
+// results to generated files or failing generation when lint queries return
+// rows.
+package codegen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/connor15mcc/pbql-go/output"
+	"github.com/connor15mcc/pbql-go/schema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// LintKey is the parameter key of lint queries.
+const LintKey = "lint"
+
+// Query is a query configured in the plugin parameter.
+type Query struct {
+	// File is the generated file the results are written to, in the format
+	// its extension names; empty for lint queries
+	File string
+	SQL  string
+}
+
+// entryStart matches the start of a parameter entry: lint= or a file name
+// with one of the output extensions. Commas followed by anything else are
+// part of the query.
+var entryStart = regexp.MustCompile(`^\s*(` + LintKey + `|[\w./-]+\.(?i:json|csv|tsv|md|markdown|txt))=`)
+
+// ParseParameter parses the plugin parameter: comma-separated entries of
+// <file>=<query> or lint=<query>. A query of @path is read from that file.
+func ParseParameter(param string) ([]Query, error) {
+	var entries []string
+	for _, part := range strings.Split(param, ",") {
+		if len(entries) > 0 && !entryStart.MatchString(part) {
+			entries[len(entries)-1] += "," + part
+			continue
+		}
+		entries = append(entries, part)
+	}
+
+	var queries []Query
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, sql, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !entryStart.MatchString(key+"=") {
+			return nil, fmt.Errorf("invalid parameter %q: expected <file>=<query> or %s=<query>", entry, LintKey)
+		}
+		if path, ok := strings.CutPrefix(strings.TrimSpace(sql), "@"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read query: %w", err)
+			}
+			sql = string(data)
+		}
+
+		q := Query{SQL: sql}
+		if key != LintKey {
+			q.File = key
+		}
+		queries = append(queries, q)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no queries configured: pass <file>=<query> or %s=<query> as the plugin parameter", LintKey)
+	}
+	return queries, nil
+}
+
+// Generate answers a code generator request. The files to generate are
+// loaded into a fresh database, the queries in the parameter run against
+// it, and each result is returned as a file. Rows returned by lint queries
+// fail the generation, one error per row. Problems are reported in the
+// response's error, as protoc expects.
+func Generate(ctx context.Context, req *pluginpb.CodeGeneratorRequest) *pluginpb.CodeGeneratorResponse {
+	resp, err := generate(ctx, req)
+	if err != nil {
+		return &pluginpb.CodeGeneratorResponse{Error: proto.String(err.Error())}
+	}
+	return resp
+}
+
+func generate(ctx context.Context, req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	queries, err := ParseParameter(req.GetParameter())
+	if err != nil {
+		return nil, err
+	}
+	files, err := linkFiles(req)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := schema.New()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if err := db.LoadFiles(files); err != nil {
+		return nil, err
+	}
+
+	resp := &pluginpb.CodeGeneratorResponse{
+		SupportedFeatures: proto.Uint64(uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL | pluginpb.CodeGeneratorResponse_FEATURE_SUPPORTS_EDITIONS)),
+		MinimumEdition:    proto.Int32(int32(descriptorpb.Edition_EDITION_PROTO2)),
+		MaximumEdition:    proto.Int32(int32(descriptorpb.Edition_EDITION_2023)),
+	}
+	var problems []string
+	for _, q := range queries {
+		if q.File == "" {
+			found, err := lint(ctx, db, q.SQL)
+			if err != nil {
+				return nil, err
+			}
+			problems = append(problems, found...)
+			continue
+		}
+
+		format, ok := output.FormatForFile(q.File)
+		if !ok {
+			return nil, fmt.Errorf("cannot infer the format of %s: use .json, .csv, .tsv, .md or .txt", q.File)
+		}
+		var buf bytes.Buffer
+		if err := output.Query(ctx, &buf, db.DB, q.SQL, format); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", q.File, err)
+		}
+		resp.File = append(resp.File, &pluginpb.CodeGeneratorResponse_File{
+			Name:    proto.String(q.File),
+			Content: proto.String(buf.String()),
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("lint failed:\n%s", strings.Join(problems, "\n"))
+	}
+	return resp, nil
+}
+
+// lint runs a lint query and describes each row it returns, joining the
+// row's values with colons, e.g. "users.proto: example.User: no comment".
+func lint(ctx context.Context, db *schema.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run lint query: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	var problems []string
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		parts := make([]string, len(values))
+		for i, val := range values {
+			parts[i] = output.Value(val)
+		}
+		problems = append(problems, strings.Join(parts, ": "))
+	}
+	return problems, rows.Err()
+}
+
+// linkFiles builds the files to generate from the request's descriptors,
+// which include all of their dependencies. Custom options arrive as unknown
+// fields, so the descriptors are decoded again with the extensions they
+// define to make the options readable.
+func linkFiles(req *pluginpb.CodeGeneratorRequest) ([]linker.File, error) {
+	registry, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: req.GetProtoFile()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptors: %w", err)
+	}
+	types := dynamicpb.NewTypes(registry)
+
+	resolved := make([]*descriptorpb.FileDescriptorProto, len(req.GetProtoFile()))
+	for i, fd := range req.GetProtoFile() {
+		data, err := proto.Marshal(fd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", fd.GetName(), err)
+		}
+		resolved[i] = &descriptorpb.FileDescriptorProto{}
+		if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(data, resolved[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", fd.GetName(), err)
+		}
+	}
+	if registry, err = protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: resolved}); err != nil {
+		return nil, fmt.Errorf("failed to build descriptors: %w", err)
+	}
+
+	return filesToGenerate(registry, req.GetFileToGenerate())
+}
+
+func filesToGenerate(registry *protoregistry.Files, names []string) ([]linker.File, error) {
+	files := make([]linker.File, 0, len(names))
+	for _, name := range names {
+		fd, err := registry.FindFileByPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s: %w", name, err)
+		}
+		f, err := linker.NewFileRecursive(fd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link %s: %w", name, err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
@@ -0,0 +1,74 @@
+package codegen
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile/protoutil"
+	"github.com/connor15mcc/pbql-go/parser"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// pluginRequest compiles a proto file into a code generator request, as
+// protoc would send it.
+func pluginRequest(t *testing.T, file, parameter string) *pluginpb.CodeGeneratorRequest {
+	t.Helper()
+	result, err := parser.ParsePaths(context.Background(), []string{file}, parser.Options{})
+	if err != nil || len(result.Files) != 1 {
+		t.Fatalf("Failed to parse %s: %v", file, err)
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{result.Files[0].Path()},
+		Parameter:      proto.String(parameter),
+	}
+	seen := make(map[string]bool)
+	var add func(protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		for i := 0; i < fd.Imports().Len(); i++ {
+			add(fd.Imports().Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protoutil.ProtoFromFileDescriptor(fd))
+	}
+	add(result.Files[0])
+	return req
+}
+
+func TestGenerate(t *testing.T) {
+	req := pluginRequest(t, "../testdata/api.proto",
+		"services.csv=SELECT name, options::VARCHAR LIKE '%owner%' AS configured FROM services ORDER BY name,"+
+			"methods.json=SELECT count(*) AS n FROM methods")
+	resp := Generate(context.Background(), req)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %s", resp.GetError())
+	}
+
+	if len(resp.File) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(resp.File))
+	}
+	if resp.File[0].GetName() != "services.csv" || resp.File[0].GetContent() != "name,configured\nAdminService,true\nUserService,true\n" {
+		t.Errorf("Unexpected services.csv: %q", resp.File[0].GetContent())
+	}
+	if resp.File[1].GetName() != "methods.json" || !strings.Contains(resp.File[1].GetContent(), `"n": `) {
+		t.Errorf("Unexpected methods.json: %q", resp.File[1].GetContent())
+	}
+}
+
+func TestGenerateLint(t *testing.T) {
+	req := pluginRequest(t, "../testdata/users.proto", "lint=SELECT file, full_name, 'should not be named Request' FROM messages WHERE name LIKE '%Request' ORDER BY full_name")
+	resp := Generate(context.Background(), req)
+
+	if !strings.HasPrefix(resp.GetError(), "lint failed:\nusers.proto: example.users.") || !strings.Contains(resp.GetError(), "example.users.CreateUserRequest: should not be named Request\n") {
+		t.Errorf("Unexpected error: %q", resp.GetError())
+	}
+	if len(resp.File) != 0 {
+		t.Errorf("Expected no files, got %d", len(resp.File))
+	}
+}
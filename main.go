@@ -3,17 +3,17 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
-	"os/user"
+	"os/signal"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/connor15mcc/pbql-go/output"
 	"github.com/connor15mcc/pbql-go/parser"
 	"github.com/connor15mcc/pbql-go/schema"
 	"github.com/connor15mcc/pbql-go/tui"
@@ -28,23 +28,7 @@ func mainE(args []string) error {
 	rootCmd := &cobra.Command{
 		Use:   "pbql-go [flags] <proto-files-or-directories...>",
 		Short: "Query protobuf definitions using SQL",
-		Long: `Query protobuf definitions using SQL.
-
-This tool allows you to explore and analyze protobuf files using SQL queries,
-similar to how you would query a database.
-
-Tables available:
-  files
-  messages
-  fields
-  enums
-  enum_values
-  services
-  methods
-  extensions
-  oneofs
-  oneof_fields
-  dependencies`,
+		Long:  longHelp,
 		Example: `  # Count methods per service
   pbql-go -q "SELECT s.name, COUNT(m.name) as method_count FROM services s LEFT JOIN methods m ON s.full_name = m.service GROUP BY s.name" ./protos/
 
@@ -57,25 +41,41 @@ Tables available:
 			query, _ := cmd.Flags().GetString("query")
 			format, _ := cmd.Flags().GetString("format")
 			verbose, _ := cmd.Flags().GetCount("verbose")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			historySize, _ := cmd.Flags().GetInt("history-size")
+			historySkipFailed, _ := cmd.Flags().GetBool("history-skip-failed")
+			file, _ := cmd.Flags().GetString("file")
+			readOnly, _ := cmd.Flags().GetBool("read-only")
 
 			if len(cmdArgs) == 0 {
 				return fmt.Errorf("at least one proto file or directory is required")
 			}
 
-			// Collect proto files
-			var protoFiles []string
-			var protoDirs []string
-			for _, arg := range cmdArgs {
-				info, err := os.Stat(arg)
+			// Read the script up front
+			var script string
+			switch {
+			case file != "" && query != "":
+				return fmt.Errorf("--query and --file cannot be used together")
+			case file != "":
+				data, err := os.ReadFile(file)
 				if err != nil {
-					return fmt.Errorf("error: %v", err)
+					return fmt.Errorf("error reading query file: %v", err)
 				}
-
-				if info.IsDir() {
-					protoDirs = append(protoDirs, arg)
-				} else {
-					protoFiles = append(protoFiles, arg)
+				script = string(data)
+			case query == "-":
+				data, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("error reading query from stdin: %v", err)
+				}
+				if strings.TrimSpace(string(data)) == "" {
+					return fmt.Errorf("no query read from stdin")
 				}
+				script = string(data)
+			}
+
+			// Check the inputs exist before doing any work
+			if err := checkInputs(cmdArgs); err != nil {
+				return err
 			}
 
 			// Initialize database
@@ -84,78 +84,60 @@ Tables available:
 				return fmt.Errorf("error initializing database: %v", err)
 			}
 			defer db.Close()
+			if readOnly {
+				if err := db.SetReadOnly(); err != nil {
+					return fmt.Errorf("error: %v", err)
+				}
+			}
 
 			ctx := context.Background()
 
-			// Set up structured logging based on verbosity level
-			var logWriter io.Writer = os.Stderr
-			var handler slog.Handler
-			if verbose == 0 {
-				handler = slog.NewTextHandler(logWriter, &slog.HandlerOptions{Level: slog.LevelError})
-			} else if verbose == 1 {
-				handler = slog.NewTextHandler(logWriter, &slog.HandlerOptions{Level: slog.LevelInfo})
-			} else {
-				handler = slog.NewTextHandler(logWriter, &slog.HandlerOptions{Level: slog.LevelDebug})
-			}
-			slog.SetDefault(slog.New(handler))
+			setupLogging(verbose)
 
-			// Parse directories
-			for _, dir := range protoDirs {
-				result, err := parser.ParseDirectory(ctx, dir, parser.Options{})
-				if err != nil {
-					return fmt.Errorf("error parsing directory %s: %v", dir, err)
-				}
-				if len(result.Errors) > 0 {
-					slog.Info("parsed directory with errors", "dir", dir, "error_count", len(result.Errors))
-					for _, e := range result.Errors {
-						slog.Debug("parse error", "error", e)
-					}
-				} else {
-					slog.Debug("parsed directory successfully", "dir", dir)
-				}
-				if err := db.LoadFiles(result.Files); err != nil {
-					return fmt.Errorf("error loading files from %s: %v", dir, err)
-				}
+			historyFile, err := tui.HistoryPath(cmdArgs)
+			if err != nil {
+				slog.Info("history disabled", "error", err)
+			}
+			legacyHistoryFile, err := tui.LegacyHistoryPath()
+			if err != nil {
+				slog.Info("legacy history will not be imported", "error", err)
+			}
+			tabsFile, err := tui.TabsPath(cmdArgs)
+			if err != nil {
+				slog.Info("tabs will not be restored", "error", err)
+			}
+			queriesDir, err := filepath.Abs(tui.QueriesDir)
+			if err != nil {
+				return fmt.Errorf("error resolving saved queries directory: %v", err)
 			}
 
-			// Parse individual files
-			if len(protoFiles) > 0 {
-				// Convert to basenames for parsing
-				baseNames := make([]string, len(protoFiles))
-				for i, f := range protoFiles {
-					baseNames[i] = filepath.Base(f)
-				}
-
-				// Change to first file's directory
-				firstDir := filepath.Dir(protoFiles[0])
-				origDir, _ := os.Getwd()
-				os.Chdir(firstDir)
-				defer os.Chdir(origDir)
+			if err := loadProtos(ctx, db, cmdArgs); err != nil {
+				return err
+			}
 
-				result, err := parser.ParseFiles(ctx, baseNames, parser.Options{ ImportPaths: []string{"."} })
-				if err != nil {
-					return fmt.Errorf("error parsing files: %v", err)
+			// Execute the script or query, or enter interactive mode
+			if script != "" || query != "" {
+				if script == "" {
+					script = query
 				}
-				if len(result.Errors) > 0 {
-					slog.Info("parsed files with errors", "error_count", len(result.Errors))
-					for _, e := range result.Errors {
-						slog.Debug("parse error", "error", e)
-					}
-				} else {
-					slog.Debug("parsed files successfully")
-				}
-				if err := db.LoadFiles(result.Files); err != nil {
-					return fmt.Errorf("error loading files: %v", err)
+				if err := db.CheckQuery(ctx, script); err != nil {
+					return fmt.Errorf("error: %v", err)
 				}
-			}
-
-			// Execute query or enter interactive mode
-			if query != "" {
-				if err := executeQuery(db.DB, query, format); err != nil {
+				if err := executeQuery(ctx, db.DB, script, format, timeout); err != nil {
 					return fmt.Errorf("error: %v", err)
 				}
 			} else {
-				if err := interactiveMode(db.DB, format); err != nil {
+				opts := tui.Options{
+					Format:            format,
+					Roots:             cmdArgs,
+					HistoryFile:       historyFile,
+					LegacyHistoryFile: legacyHistoryFile,
+					HistorySize:       historySize,
+					HistorySkipFailed: historySkipFailed,
+					TabsFile:          tabsFile,
+					QueriesDir:        queriesDir,
+				}
+				if err := interactiveMode(db, opts); err != nil {
 					return err
 				}
 			}
@@ -163,178 +145,157 @@ Tables available:
 		},
 	}
 
-	rootCmd.Flags().StringP("query", "q", "", "SQL query to execute")
+	rootCmd.Flags().StringP("query", "q", "", "SQL query to execute; ;-separated statements run in order, - reads them from stdin")
+	rootCmd.Flags().String("file", "", "File of ;-separated SQL statements to execute in order")
 	rootCmd.Flags().StringP("format", "f", "table", "Output format: table, json, csv")
-	rootCmd.Flags().CountP("verbose", "v", "Increase verbosity (specify multiple times: -v, -vv, -vvv)")
+	rootCmd.PersistentFlags().CountP("verbose", "v", "Increase verbosity (specify multiple times: -v, -vv, -vvv)")
+	rootCmd.Flags().Duration("timeout", 0, "Cancel the query if it runs longer than this (e.g. 30s); 0 means no limit")
+	rootCmd.Flags().Int("history-size", tui.DefaultHistorySize, "Number of distinct queries kept in the interactive history; 0 means no limit")
+	rootCmd.Flags().Bool("history-skip-failed", false, "Don't record interactive queries that fail in the history")
+	rootCmd.Flags().Bool("read-only", false, "Only allow SELECT statements, and keep DuckDB from accessing files or installing extensions")
+
+	// Inputs are positional arguments alongside the subcommands
+	rootCmd.Args = cobra.ArbitraryArgs
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(serveCommand())
+	rootCmd.AddCommand(mcpCommand())
+
+	// The table list is read from the catalog, only when help is shown
+	defaultHelp := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if cmd == rootCmd {
+			cmd.Long = longHelp + "\n\n" + catalogHelp()
+		}
+		defaultHelp(cmd, args)
+	})
 
 	return rootCmd.Execute()
 }
 
-func main() {
-	err := mainE(os.Args[1:])
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-
-}
+const longHelp = `Query protobuf definitions using SQL.
 
-func interactiveMode(db *sql.DB, format string) error {
-	return tui.Run(db, format)
-}
+This tool allows you to explore and analyze protobuf files using SQL queries,
+similar to how you would query a database.`
 
-func executeQuery(db *sql.DB, query, format string) error {
-	rows, err := db.Query(query)
+// catalogHelp lists the tables and views with their descriptions, read from
+// a fresh database so the help always matches the schema.
+func catalogHelp() string {
+	db, err := schema.New()
 	if err != nil {
-		return err
+		return ""
 	}
-	defer rows.Close()
+	defer db.Close()
 
-	cols, err := rows.Columns()
+	tables, err := schema.Catalog(context.Background(), db.DB)
 	if err != nil {
-		return err
-	}
-
-	switch format {
-	case "json":
-		return outputJSON(rows, cols)
-	case "csv":
-		return outputCSV(rows, cols)
-	default:
-		return outputTable(rows, cols)
+		return ""
 	}
-}
-
-func outputTable(rows *sql.Rows, cols []string) error {
-	// Collect all data first to calculate column widths
-	var data [][]string
-	colWidths := make([]int, len(cols))
 
-	for i, col := range cols {
-		colWidths[i] = len(col)
+	width := 0
+	for _, t := range tables {
+		width = max(width, len(t.Name))
 	}
 
-	for rows.Next() {
-		values := make([]any, len(cols))
-		valuePtrs := make([]any, len(cols))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return err
-		}
-
-		row := make([]string, len(cols))
-		for i, val := range values {
-			row[i] = formatValue(val)
-			if len(row[i]) > colWidths[i] {
-				colWidths[i] = len(row[i])
+	var b strings.Builder
+	kind := ""
+	for _, t := range tables {
+		if t.Type != kind {
+			if kind != "" {
+				b.WriteString("\n")
 			}
+			kind = t.Type
+			fmt.Fprintf(&b, "%ss available:\n", strings.ToUpper(kind[:1])+kind[1:])
 		}
-		data = append(data, row)
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, t.Name, t.Comment)
 	}
+	b.WriteString("\nIn interactive mode, .schema shows every column with its type and description.")
+	return b.String()
+}
 
-	// Print header
-	printTableRow(cols, colWidths)
-	printTableSeparator(colWidths)
-
-	// Print data
-	for _, row := range data {
-		printTableRow(row, colWidths)
+func main() {
+	err := mainE(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("(%d rows)\n", len(data))
-	return rows.Err()
 }
 
-func printTableRow(values []string, widths []int) {
-	for i, val := range values {
-		fmt.Printf("%-*s", widths[i]+2, val)
+// checkInputs reports the first proto file or directory that does not
+// exist.
+func checkInputs(paths []string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("error: %v", err)
+		}
 	}
-	fmt.Println()
+	return nil
 }
 
-func printTableSeparator(widths []int) {
-	for _, w := range widths {
-		fmt.Print(strings.Repeat("-", w+2))
+// setupLogging sends structured logs to stderr, at a level based on the
+// number of -v flags.
+func setupLogging(verbose int) {
+	level := slog.LevelError
+	if verbose == 1 {
+		level = slog.LevelInfo
+	} else if verbose > 1 {
+		level = slog.LevelDebug
 	}
-	fmt.Println()
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
 }
 
-func outputJSON(rows *sql.Rows, cols []string) error {
-	var results []map[string]any
-
-	for rows.Next() {
-		values := make([]any, len(cols))
-		valuePtrs := make([]any, len(cols))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return err
-		}
-
-		row := make(map[string]any)
-		for i, col := range cols {
-			row[col] = values[i]
+// loadProtos parses proto files and directories into db. Files that fail to
+// compile are logged and skipped.
+func loadProtos(ctx context.Context, db *schema.DB, paths []string) error {
+	result, err := parser.ParsePaths(ctx, paths, parser.Options{})
+	if err != nil {
+		return fmt.Errorf("error parsing protos: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		slog.Info("parsed protos with errors", "error_count", len(result.Errors))
+		for _, e := range result.Errors {
+			slog.Debug("parse error", "error", e)
 		}
-		results = append(results, row)
+	} else {
+		slog.Debug("parsed protos successfully", "files", len(result.Files))
 	}
-
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(results)
-}
-
-func outputCSV(rows *sql.Rows, cols []string) error {
-	writer := csv.NewWriter(os.Stdout)
-	defer writer.Flush()
-
-	if err := writer.Write(cols); err != nil {
-		return err
+	if err := db.LoadFiles(result.Files); err != nil {
+		return fmt.Errorf("error loading files: %v", err)
 	}
+	return nil
+}
 
-	for rows.Next() {
-		values := make([]any, len(cols))
-		valuePtrs := make([]any, len(cols))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return err
-		}
+func interactiveMode(db *schema.DB, opts tui.Options) error {
+	return tui.Run(db, opts)
+}
 
-		row := make([]string, len(cols))
-		for i, val := range values {
-			row[i] = formatValue(val)
-		}
+// executeQuery runs a query, or each statement of a multi-statement script
+// in order, cancelling it on interrupt (Ctrl+C) or when the timeout elapses.
+func executeQuery(ctx context.Context, db *sql.DB, query, format string, timeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
 
-		if err := writer.Write(row); err != nil {
-			return err
-		}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	return rows.Err()
-}
-
-func formatValue(val any) string {
-	if val == nil {
-		return "NULL"
+	var err error
+	if len(output.Split(query)) != 1 {
+		err = output.Script(ctx, os.Stdout, db, query, format)
+	} else {
+		err = output.Query(ctx, os.Stdout, db, query, format)
 	}
-	switch v := val.(type) {
-	case []byte:
-		return string(v)
-	case bool:
-		if v {
-			return "true"
-		}
-		return "false"
-	default:
-		return fmt.Sprintf("%v", v)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("query timed out after %s", timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("query cancelled")
 	}
+	return err
 }
 
 // stringSlice implements flag.Value for collecting multiple string flags
@@ -348,28 +309,3 @@ func (s *stringSlice) Set(value string) error {
 	*s = append(*s, value)
 	return nil
 }
-
-func getHistoryPath() (string, error) {
-	usr, err := user.Current()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(usr.HomeDir, ".pbql_history"), nil
-}
-
-func appendToHistory(query string) error {
-	path, err := getHistoryPath()
-	if err != nil {
-		return err
-	}
-
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	timestamp := time.Now().Format(time.RFC3339)
-	_, err = f.WriteString(fmt.Sprintf("# %s\n%s\n\n", timestamp, query))
-	return err
-}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/connor15mcc/pbql-go/mcp"
+	"github.com/connor15mcc/pbql-go/schema"
+	"github.com/spf13/cobra"
+)
+
+const mcpHelp = `Load the protos and serve them to AI assistants over the Model Context
+Protocol on stdin and stdout, with tools to:
+
+  query        run a SELECT statement, with results as JSON rows
+  describe     show a proto element's definition or a table's columns
+  list_tables  list the tables and views with their columns
+  find_usages  find the fields, methods and extensions using a type
+
+Configure it in the assistant as a stdio server running this command. Like
+serve, it always runs in --read-only mode.`
+
+func mcpCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp [flags] <proto-files-or-directories...>",
+		Short: "Serve the protos to AI assistants over MCP on stdio",
+		Long:  mcpHelp,
+		Example: `  pbql-go mcp ./protos/
+  pbql-go mcp --max-rows 100 --timeout 30s ./protos/`,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			maxRows, _ := cmd.Flags().GetInt("max-rows")
+			verbose, _ := cmd.Flags().GetCount("verbose")
+
+			if len(cmdArgs) == 0 {
+				return fmt.Errorf("at least one proto file or directory is required")
+			}
+			if err := checkInputs(cmdArgs); err != nil {
+				return err
+			}
+			setupLogging(verbose)
+
+			db, err := schema.New()
+			if err != nil {
+				return fmt.Errorf("error initializing database: %v", err)
+			}
+			defer db.Close()
+			if err := db.SetReadOnly(); err != nil {
+				return fmt.Errorf("error: %v", err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := loadProtos(ctx, db, cmdArgs); err != nil {
+				return err
+			}
+
+			srv := mcp.New(db, mcp.Options{Timeout: timeout, MaxRows: maxRows})
+			if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("error serving: %v", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Duration("timeout", 0, "Cancel queries running longer than this (e.g. 30s); 0 means no limit")
+	cmd.Flags().Int("max-rows", mcp.DefaultMaxRows, "Maximum number of rows a query returns")
+	return cmd
+}
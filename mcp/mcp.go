@@ -0,0 +1,220 @@
+// Package mcp serves a loaded schema to AI assistants over the Model Context
+// Protocol, so they can answer questions about an API from its actual
+// protos. Messages are JSON-RPC 2.0, one per line, as in MCP's stdio
+// transport.
+//
+// The server offers tools, not resources or prompts:
+//
+//	query        run a SELECT statement, with results as JSON rows
+//	describe     the definition of a proto element, or a table's columns
+//	list_tables  the tables and views with their columns
+//	find_usages  the fields, methods and extensions using a message or enum
+//
+// Only SELECT statements are accepted; the database should also be made
+// read-only with schema.DB.SetReadOnly, to keep queries away from files.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// ProtocolVersion is the latest MCP version supported, used unless the
+// client asks for an older one.
+const ProtocolVersion = "2025-06-18"
+
+// protocolVersions are the MCP versions supported.
+var protocolVersions = []string{"2024-11-05", "2025-03-26", ProtocolVersion}
+
+// maxMessageSize bounds the messages accepted from clients.
+const maxMessageSize = 16 << 20
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Options configures the server.
+type Options struct {
+	// Timeout cancels queries running longer than this; 0 means no limit
+	Timeout time.Duration
+	// MaxRows bounds the rows returned by a query, to keep results within
+	// an assistant's context; 0 means DefaultMaxRows
+	MaxRows int
+}
+
+// DefaultMaxRows is the number of rows a query returns by default.
+const DefaultMaxRows = 500
+
+// Server answers MCP requests for a schema.
+type Server struct {
+	db   *schema.DB
+	opts Options
+}
+
+// New returns a server for a loaded schema.
+func New(db *schema.DB, opts Options) *Server {
+	if opts.MaxRows <= 0 {
+		opts.MaxRows = DefaultMaxRows
+	}
+	return &Server{db: db, opts: opts}
+}
+
+// request is a JSON-RPC request, or a notification when it has no ID.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads requests from r and writes responses to w, one JSON message
+// per line, until r ends or ctx is done. Requests are handled in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageSize)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		resp := s.handle(ctx, line)
+		if resp == nil {
+			continue
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message, returning nil for notifications.
+func (s *Server) handle(ctx context.Context, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &response{JSONRPC: "2.0", ID: orNull(req.ID), Error: &rpcError{codeInvalidRequest, "not a JSON-RPC 2.0 request"}}
+	}
+
+	result, err := s.call(ctx, req.Method, req.Params)
+	if req.ID == nil {
+		if err != nil {
+			slog.Debug("mcp notification failed", "method", req.Method, "error", err)
+		}
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{codeInvalidParams, err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	return resp
+}
+
+func orNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// call runs a method.
+func (s *Server) call(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		version := ProtocolVersion
+		if slices.Contains(protocolVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "pbql-go", "version": serverVersion()},
+			"instructions":    instructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.callTool(ctx, p.Name, p.Arguments)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", method)}
+}
+
+func unmarshalParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{codeInvalidParams, fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// serverVersion is the module version pbql-go was built from, if known.
+func serverVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
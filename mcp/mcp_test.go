@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/parser"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// testDB loads the testdata protos in read-only mode, as the mcp command
+// does.
+func testDB(t *testing.T) *schema.DB {
+	t.Helper()
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	result, err := parser.ParsePaths(context.Background(), []string{"../testdata"}, parser.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.LoadFiles(result.Files); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.SetReadOnly(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return db
+}
+
+// session sends requests to an MCP server for the testdata protos,
+// returning its responses by ID.
+func session(t *testing.T, requests ...string) map[string]map[string]any {
+	t.Helper()
+	srv := New(testDB(t), Options{MaxRows: 3})
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := make(map[string]map[string]any)
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp map[string]any
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("Unexpected error decoding %q: %v", out.String(), err)
+		}
+		responses[fmt.Sprint(resp["id"])] = resp
+	}
+	return responses
+}
+
+// toolText returns the text of a tool call result, and whether it is an
+// error.
+func toolText(resp map[string]any) (string, bool) {
+	result, _ := resp["result"].(map[string]any)
+	content, _ := result["content"].([]any)
+	if len(content) != 1 {
+		return "", true
+	}
+	text, _ := content[0].(map[string]any)["text"].(string)
+	return text, result["isError"] == true
+}
+
+func TestServe(t *testing.T) {
+	responses := session(t,
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {}, "clientInfo": {"name": "test", "version": "1"}}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "query", "arguments": {"sql": "SELECT name FROM services ORDER BY name"}}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "describe", "arguments": {"full_name": "example.users.User"}}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "find_usages", "arguments": {"type": "example.api.User"}}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "tools/call", "params": {"name": "list_tables", "arguments": {}}}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "tools/call", "params": {"name": "find_usages", "arguments": {"type": "example.common.Timestamp"}}}`,
+	)
+	if len(responses) != 7 {
+		t.Fatalf("Expected 7 responses, got %v", responses)
+	}
+
+	result, _ := responses["1"]["result"].(map[string]any)
+	if result["protocolVersion"] != ProtocolVersion || result["capabilities"].(map[string]any)["tools"] == nil {
+		t.Errorf("Unexpected initialize result: %v", result)
+	}
+	result, _ = responses["2"]["result"].(map[string]any)
+	if tools, _ := result["tools"].([]any); len(tools) != 4 {
+		t.Errorf("Expected 4 tools, got %v", result)
+	}
+
+	text, isError := toolText(responses["3"])
+	if isError || !strings.Contains(text, `"name": "AdminService"`) || !strings.Contains(text, "only the first 3 rows") {
+		t.Errorf("Unexpected query result: %s", text)
+	}
+	text, isError = toolText(responses["4"])
+	if isError || !strings.Contains(text, "message User {") || !strings.Contains(text, "users.proto") {
+		t.Errorf("Unexpected describe result: %s", text)
+	}
+	text, isError = toolText(responses["5"])
+	if isError || !strings.Contains(text, "example.api.GetUserResponse.user") {
+		t.Errorf("Unexpected find_usages result: %s", text)
+	}
+	text, isError = toolText(responses["6"])
+	if isError || !strings.Contains(text, `"name": "fields"`) || !strings.Contains(text, `"columns"`) {
+		t.Errorf("Unexpected list_tables result: %.200s", text)
+	}
+	text, isError = toolText(responses["7"])
+	if isError || !strings.Contains(text, "only the first 3 uses") {
+		t.Errorf("Expected truncated usages, got: %s", text)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	responses := session(t,
+		`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "query", "arguments": {"sql": "DELETE FROM fields"}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "describe", "arguments": {"full_name": "example.NoSuchMessage"}}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "query", "arguments": {}}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "resources/list"}`,
+		`not json`,
+	)
+
+	if text, isError := toolText(responses["1"]); !isError || !strings.Contains(text, "only SELECT statements are allowed") {
+		t.Errorf("Expected the write to be rejected, got %s", text)
+	}
+	if text, isError := toolText(responses["2"]); !isError || !strings.Contains(text, "no proto element or table") {
+		t.Errorf("Expected an unknown name error, got %s", text)
+	}
+	for id, code := range map[string]float64{"3": -32602, "4": -32601, "<nil>": -32700} {
+		rpcErr, _ := responses[id]["error"].(map[string]any)
+		if rpcErr["code"] != code {
+			t.Errorf("Expected error %v for request %s, got %v", code, id, responses[id])
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/connor15mcc/pbql-go/output"
+	"github.com/connor15mcc/pbql-go/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// instructions tell the assistant how to use the tools.
+const instructions = `These tools query an index of protobuf definitions (files, messages, fields, enums, services, methods, options, ...) held in DuckDB tables. Start with list_tables to learn the tables and columns, then use query to answer questions with SQL in DuckDB's dialect. Proto elements are identified by their fully qualified names, without a leading dot. Use describe to read an element's definition and comments, and find_usages to see where a message or enum is used.`
+
+// tool describes a tool in tools/list.
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// stringArg is the input schema of a tool taking one string argument.
+func stringArg(name, description string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			name: map[string]string{"type": "string", "description": description},
+		},
+		"required": []string{name},
+	}
+}
+
+var tools = []tool{
+	{
+		Name:        "query",
+		Description: "Run a read-only SQL query (a SELECT statement in DuckDB's dialect) against the proto index, returning the rows as JSON.",
+		InputSchema: stringArg("sql", "The SELECT statement to run"),
+	},
+	{
+		Name:        "describe",
+		Description: "Show the definition of a proto element (message, enum, service, method, field, ...) as proto source with its comments and options, or the columns of a table.",
+		InputSchema: stringArg("full_name", "A fully qualified proto name such as example.api.User, or a table name"),
+	},
+	{
+		Name:        "list_tables",
+		Description: "List the tables and views of the proto index with their columns and descriptions.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	},
+	{
+		Name:        "find_usages",
+		Description: "Find the fields, extensions and RPC methods that use a message or enum, and the extensions of a message.",
+		InputSchema: stringArg("type", "The fully qualified name of a message or enum"),
+	},
+}
+
+// usagesQuery finds the uses of a type. The fields of map entries are
+// reported as the map fields themselves.
+const usagesQuery = `
+SELECT 'field' AS kind, id AS name
+FROM fields
+WHERE (type_name = $1 OR map_value_type = $1)
+  AND message NOT IN (SELECT full_name FROM messages WHERE is_map_entry)
+UNION ALL
+SELECT 'extension', full_name FROM extensions WHERE type_name = $1
+UNION ALL
+SELECT 'method input', full_name FROM methods WHERE input_type = $1
+UNION ALL
+SELECT 'method output', full_name FROM methods WHERE output_type = $1
+UNION ALL
+SELECT 'extended by', full_name FROM extensions WHERE extendee = $1
+ORDER BY kind, name`
+
+// callTool runs a tool. Failures of the tool itself, such as a bad query,
+// are results for the assistant to see rather than protocol errors.
+func (s *Server) callTool(ctx context.Context, name string, arguments json.RawMessage) (any, error) {
+	var args map[string]any
+	if err := unmarshalParams(arguments, &args); err != nil {
+		return nil, err
+	}
+	arg := func(key string) (string, error) {
+		v, ok := args[key].(string)
+		if !ok || strings.TrimSpace(v) == "" {
+			return "", &rpcError{codeInvalidParams, fmt.Sprintf("tool %s requires a %q string argument", name, key)}
+		}
+		return v, nil
+	}
+
+	var text string
+	var err error
+	switch name {
+	case "query":
+		var sql string
+		if sql, err = arg("sql"); err != nil {
+			return nil, err
+		}
+		text, err = s.query(ctx, sql)
+	case "describe":
+		var fullName string
+		if fullName, err = arg("full_name"); err != nil {
+			return nil, err
+		}
+		text, err = s.describe(ctx, fullName)
+	case "list_tables":
+		text, err = s.listTables(ctx)
+	case "find_usages":
+		var typeName string
+		if typeName, err = arg("type"); err != nil {
+			return nil, err
+		}
+		text, err = s.findUsages(ctx, typeName)
+	default:
+		return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", name)}
+	}
+
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+	return toolResult(text, false), nil
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// query runs a SELECT statement, returning up to MaxRows rows as JSON.
+func (s *Server) query(ctx context.Context, query string) (string, error) {
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+	if err := schema.CheckReadOnly(ctx, s.db.DB, query); err != nil {
+		return "", err
+	}
+
+	cols, values, truncated, err := s.rows(ctx, query)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("query timed out after %s", s.opts.Timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := output.Values(&buf, cols, values, output.JSON); err != nil {
+		return "", err
+	}
+	if truncated {
+		fmt.Fprintf(&buf, "\n(only the first %d rows are shown: add a LIMIT or narrow the query)\n", s.opts.MaxRows)
+	}
+	return buf.String(), nil
+}
+
+// rows runs a query and scans up to MaxRows of its rows.
+func (s *Server) rows(ctx context.Context, query string, args ...any) (cols []string, values [][]any, truncated bool, err error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer rows.Close()
+
+	if cols, err = rows.Columns(); err != nil {
+		return nil, nil, false, err
+	}
+	for rows.Next() {
+		if len(values) == s.opts.MaxRows {
+			truncated = true
+			break
+		}
+		row := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, false, err
+		}
+		values = append(values, row)
+	}
+	return cols, values, truncated, rows.Err()
+}
+
+// describe renders a proto element's definition, or a table's columns.
+func (s *Server) describe(ctx context.Context, name string) (string, error) {
+	if desc := s.db.Descriptor(name); desc != nil {
+		return fmt.Sprintf("// %s, defined in %s\n%s", desc.FullName(), desc.ParentFile().Path(), schema.Definition(desc)), nil
+	}
+
+	tables, err := schema.Catalog(ctx, s.db.DB)
+	if err != nil {
+		return "", err
+	}
+	if i := slices.IndexFunc(tables, func(t schema.Table) bool { return t.Name == name }); i >= 0 {
+		return marshal(tables[i])
+	}
+	return "", fmt.Errorf("no proto element or table named %s: use query to search the messages, enums and services tables by name", name)
+}
+
+func (s *Server) listTables(ctx context.Context) (string, error) {
+	tables, err := schema.Catalog(ctx, s.db.DB)
+	if err != nil {
+		return "", err
+	}
+	return marshal(tables)
+}
+
+// findUsages lists the uses of a message or enum.
+func (s *Server) findUsages(ctx context.Context, typeName string) (string, error) {
+	desc := s.db.Descriptor(typeName)
+	switch desc.(type) {
+	case protoreflect.MessageDescriptor, protoreflect.EnumDescriptor:
+	default:
+		return "", fmt.Errorf("no message or enum named %s", typeName)
+	}
+
+	cols, values, truncated, err := s.rows(ctx, usagesQuery, string(desc.FullName()))
+	if err != nil {
+		return "", err
+	}
+	if len(values) == 0 {
+		return fmt.Sprintf("%s is not used by any field, method or extension", desc.FullName()), nil
+	}
+	var buf bytes.Buffer
+	if err := output.Values(&buf, cols, values, output.JSON); err != nil {
+		return "", err
+	}
+	if truncated {
+		fmt.Fprintf(&buf, "\n(only the first %d uses are shown: use query to list them all)\n", s.opts.MaxRows)
+	}
+	return buf.String(), nil
+}
+
+func marshal(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,252 @@
+package output
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Supported output formats.
+const (
+	Table = "table"
+	JSON  = "json"
+	CSV   = "csv"
+
+	// TSV and Markdown are only used to copy and export results from the
+	// interactive mode.
+	TSV      = "tsv"
+	Markdown = "markdown"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{Table, JSON, CSV}
+
+// Valid reports whether format is a supported output format.
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Query executes a query and writes its results to w in the given format.
+// Unknown formats fall back to table. Cancelling ctx interrupts the query.
+func Query(ctx context.Context, w io.Writer, db *sql.DB, query, format string) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return Rows(w, rows, format)
+}
+
+// Rows writes a result set to w in the given format.
+func Rows(w io.Writer, rows *sql.Rows, format string) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var values [][]any
+	for rows.Next() {
+		row, err := scanRow(rows, len(cols))
+		if err != nil {
+			return err
+		}
+		values = append(values, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return Values(w, cols, values, format)
+}
+
+// Values writes rows that were already scanned to w in the given format.
+// Besides the output formats it accepts TSV and Markdown, used to copy
+// results from the interactive mode.
+func Values(w io.Writer, cols []string, values [][]any, format string) error {
+	switch format {
+	case JSON:
+		return writeJSON(w, cols, values)
+	case CSV:
+		return writeCSV(w, cols, values, ',')
+	case TSV:
+		return writeCSV(w, cols, values, '\t')
+	case Markdown:
+		writeMarkdown(w, cols, values)
+		return nil
+	default:
+		writeTable(w, cols, values)
+		return nil
+	}
+}
+
+// FormatForFile infers the format to export to from a file's extension:
+// .json, .csv, .tsv, .md or .markdown, and .txt for a table.
+func FormatForFile(path string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, true
+	case ".csv":
+		return CSV, true
+	case ".tsv":
+		return TSV, true
+	case ".md", ".markdown":
+		return Markdown, true
+	case ".txt":
+		return Table, true
+	}
+	return "", false
+}
+
+func writeTable(w io.Writer, cols []string, values [][]any) {
+	// Format all data first to calculate column widths
+	data := make([][]string, len(values))
+	colWidths := make([]int, len(cols))
+
+	for i, col := range cols {
+		colWidths[i] = len(col)
+	}
+
+	for r, vals := range values {
+		row := make([]string, len(cols))
+		for i, val := range vals {
+			row[i] = Value(val)
+			if len(row[i]) > colWidths[i] {
+				colWidths[i] = len(row[i])
+			}
+		}
+		data[r] = row
+	}
+
+	// Print header
+	writeTableRow(w, cols, colWidths)
+	writeTableSeparator(w, colWidths)
+
+	// Print data
+	for _, row := range data {
+		writeTableRow(w, row, colWidths)
+	}
+
+	fmt.Fprintf(w, "(%d rows)\n", len(data))
+}
+
+func writeTableRow(w io.Writer, values []string, widths []int) {
+	for i, val := range values {
+		fmt.Fprintf(w, "%-*s", widths[i]+2, val)
+	}
+	fmt.Fprintln(w)
+}
+
+func writeTableSeparator(w io.Writer, widths []int) {
+	for _, width := range widths {
+		fmt.Fprint(w, strings.Repeat("-", width+2))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeJSON(w io.Writer, cols []string, values [][]any) error {
+	var results []map[string]any
+
+	for _, vals := range values {
+		row := make(map[string]any)
+		for i, col := range cols {
+			row[col] = vals[i]
+		}
+		results = append(results, row)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+func writeCSV(w io.Writer, cols []string, values [][]any, comma rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	if err := writer.Write(cols); err != nil {
+		return err
+	}
+
+	for _, vals := range values {
+		row := make([]string, len(cols))
+		for i, val := range vals {
+			row[i] = Value(val)
+		}
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeMarkdown writes a GitHub-flavored Markdown table. Pipes are escaped
+// and newlines replaced so that each row stays on one line.
+func writeMarkdown(w io.Writer, cols []string, values [][]any) {
+	cell := strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+	writeRow := func(cells []string) {
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	header := make([]string, len(cols))
+	separator := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = cell.Replace(col)
+		separator[i] = "---"
+	}
+	writeRow(header)
+	writeRow(separator)
+
+	for _, vals := range values {
+		row := make([]string, len(cols))
+		for i, val := range vals {
+			row[i] = cell.Replace(Value(val))
+		}
+		writeRow(row)
+	}
+}
+
+// scanRow scans the current row into a slice of untyped values.
+func scanRow(rows *sql.Rows, n int) ([]any, error) {
+	values := make([]any, n)
+	valuePtrs := make([]any, n)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Value formats a single scanned value for display.
+func Value(val any) string {
+	if val == nil {
+		return "NULL"
+	}
+	switch v := val.(type) {
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
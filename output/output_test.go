@@ -0,0 +1,76 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	cols := []string{"name", "note", "n"}
+	values := [][]any{
+		{"User", "a, \"quoted\" | note", int64(1)},
+		{"Order", nil, true},
+	}
+	for format, want := range map[string]string{
+		Table: "name   note                n     \n" +
+			"---------------------------------\n" +
+			"User   a, \"quoted\" | note  1     \n" +
+			"Order  NULL                true  \n" +
+			"(2 rows)\n",
+		JSON: "[\n" +
+			"  {\n    \"n\": 1,\n    \"name\": \"User\",\n    \"note\": \"a, \\\"quoted\\\" | note\"\n  },\n" +
+			"  {\n    \"n\": true,\n    \"name\": \"Order\",\n    \"note\": null\n  }\n" +
+			"]\n",
+		CSV: "name,note,n\n" +
+			"User,\"a, \"\"quoted\"\" | note\",1\n" +
+			"Order,NULL,true\n",
+		TSV: "name\tnote\tn\n" +
+			"User\t\"a, \"\"quoted\"\" | note\"\t1\n" +
+			"Order\tNULL\ttrue\n",
+		Markdown: "| name | note | n |\n" +
+			"| --- | --- | --- |\n" +
+			"| User | a, \"quoted\" \\| note | 1 |\n" +
+			"| Order | NULL | true |\n",
+	} {
+		var buf bytes.Buffer
+		if err := Values(&buf, cols, values, format); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", format, err)
+		}
+		if buf.String() != want {
+			t.Errorf("Unexpected %s output:\n%s\nwant:\n%s", format, buf.String(), want)
+		}
+	}
+}
+
+func TestValuesEmpty(t *testing.T) {
+	for format, want := range map[string]string{
+		JSON: "null\n",
+		CSV:  "name\n",
+	} {
+		var buf bytes.Buffer
+		if err := Values(&buf, []string{"name"}, nil, format); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", format, err)
+		}
+		if buf.String() != want {
+			t.Errorf("Unexpected empty %s output %q, want %q", format, buf.String(), want)
+		}
+	}
+}
+
+func TestFormatForFile(t *testing.T) {
+	for path, want := range map[string]string{
+		"rows.json":        JSON,
+		"rows.CSV":         CSV,
+		"out/rows.tsv":     TSV,
+		"rows.md":          Markdown,
+		"rows.markdown":    Markdown,
+		"rows.txt":         Table,
+		"rows":             "",
+		"rows.json.backup": "",
+	} {
+		got, ok := FormatForFile(path)
+		if got != want || ok != (want != "") {
+			t.Errorf("FormatForFile(%q) = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+}
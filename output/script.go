@@ -0,0 +1,186 @@
+package output
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Statement is one statement of a script.
+type Statement struct {
+	SQL string
+	// Offset is the byte offset of the statement in the script and Line
+	// the (1-based) line it starts on.
+	Offset int
+	Line   int
+}
+
+// ScriptError is the failure of one statement of a script.
+type ScriptError struct {
+	Index     int
+	Statement Statement
+	Err       error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("statement %d (line %d): %v", e.Index+1, e.Statement.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// rowKeywords are the leading keywords of statements that produce results
+// worth printing in a script; other statements (DDL, SET, ...) are run
+// silently.
+var rowKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "FROM": true, "VALUES": true, "TABLE": true,
+	"SHOW": true, "DESCRIBE": true, "SUMMARIZE": true, "EXPLAIN": true,
+	"PRAGMA": true, "CALL": true,
+}
+
+// Split splits a script into its ;-separated statements, ignoring
+// semicolons inside string literals, quoted identifiers, dollar-quoted
+// strings and comments. Statements start at their first token, so comments
+// before it are dropped, as are statements that are empty or only comments.
+func Split(script string) []Statement {
+	var stmts []Statement
+	line := 1
+	// start is the offset of the current statement's first token, or -1
+	// before it
+	start, startLine := -1, 0
+	begin := func(i int) {
+		if start < 0 {
+			start, startLine = i, line
+		}
+	}
+	add := func(end int) {
+		if start >= 0 {
+			stmts = append(stmts, Statement{
+				SQL:    strings.TrimRight(script[start:end], " \t\r\n"),
+				Offset: start,
+				Line:   startLine,
+			})
+		}
+		start = -1
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+				break
+			}
+			i += end - 1
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			line += strings.Count(script[i:i+2+end], "\n")
+			i += end + 3
+		case c == '\'' || c == '"':
+			begin(i)
+			i = skipQuoted(script, i, c, &line)
+		case c == '$' && strings.HasPrefix(script[i:], "$$"):
+			begin(i)
+			end := strings.Index(script[i+2:], "$$")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			line += strings.Count(script[i:i+2+end], "\n")
+			i += end + 3
+		case c == ';':
+			add(i)
+		case c != ' ' && c != '\t' && c != '\r':
+			begin(i)
+		}
+	}
+	add(len(script))
+	return stmts
+}
+
+// skipQuoted returns the index of the quote closing the literal starting at
+// i. A doubled quote is an escaped quote.
+func skipQuoted(s string, i int, quote byte, line *int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\n':
+			*line++
+		case quote:
+			if j+1 < len(s) && s[j+1] == quote {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(s)
+}
+
+// ReturnsRows reports whether a statement produces results worth printing,
+// judged by its first keyword.
+func ReturnsRows(stmt string) bool {
+	stmt = stripLeadingComments(stmt)
+	if strings.HasPrefix(stmt, "(") {
+		return true
+	}
+	word := stmt
+	if end := strings.IndexFunc(stmt, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		word = stmt[:end]
+	}
+	return rowKeywords[strings.ToUpper(word)]
+}
+
+func stripLeadingComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			_, rest, ok := strings.Cut(stmt, "\n")
+			if !ok {
+				return ""
+			}
+			stmt = rest
+		case strings.HasPrefix(stmt, "/*"):
+			_, rest, ok := strings.Cut(stmt, "*/")
+			if !ok {
+				return ""
+			}
+			stmt = rest
+		default:
+			return stmt
+		}
+	}
+}
+
+// Script executes the statements of a script in order, writing the results
+// of each row-returning statement to w, separated by blank lines. It stops
+// at the first failing statement, returning a *ScriptError.
+func Script(ctx context.Context, w io.Writer, db *sql.DB, script, format string) error {
+	printed := false
+	for i, stmt := range Split(script) {
+		var err error
+		if ReturnsRows(stmt.SQL) {
+			if printed && format != JSON {
+				fmt.Fprintln(w)
+			}
+			err = Query(ctx, w, db, stmt.SQL, format)
+			printed = true
+		} else {
+			_, err = db.ExecContext(ctx, stmt.SQL)
+		}
+		if err != nil {
+			return &ScriptError{Index: i, Statement: stmt, Err: err}
+		}
+	}
+	return nil
+}
@@ -6,7 +6,9 @@ import (
 	"io/fs"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/bufbuild/protocompile"
 	"github.com/bufbuild/protocompile/linker"
@@ -19,8 +21,14 @@ type Result struct {
 }
 
 type Options struct {
-	// ImportPaths specifies directories to search for imports.
+	// ImportPaths specifies directories to search for imports, after the
+	// directory being parsed.
 	ImportPaths []string
+	// Includes limits the files parsed from directories to those matching
+	// one of these glob patterns (path.Match syntax), against their path
+	// relative to the directory, or their base name for patterns without a
+	// slash. Imports are still resolved from every file.
+	Includes []string
 }
 
 // ParseFiles parses the given proto files and returns the compiled result.
@@ -47,6 +55,8 @@ func ParseFiles(ctx context.Context, files []string, opts Options) (*Result, err
 	compiler := &protocompile.Compiler{
 		Resolver: protocompile.WithStandardImports(resolver),
 		Reporter: rep,
+		// Keep source locations, for comments and jumping to definitions
+		SourceInfoMode: protocompile.SourceInfoStandard,
 	}
 
 	linked, err := compiler.Compile(ctx, files...)
@@ -71,6 +81,8 @@ func ParseFiles(ctx context.Context, files []string, opts Options) (*Result, err
 	return result, nil
 }
 
+// ParseDirectory parses the proto files under dir, which is also their
+// import path, followed by opts.ImportPaths.
 func ParseDirectory(ctx context.Context, dir string, opts Options) (*Result, error) {
 	var protoFiles []string
 
@@ -84,7 +96,9 @@ func ParseDirectory(ctx context.Context, dir string, opts Options) (*Result, err
 			if err != nil {
 				return err
 			}
-			protoFiles = append(protoFiles, relPath)
+			if opts.included(filepath.ToSlash(relPath)) {
+				protoFiles = append(protoFiles, relPath)
+			}
 		}
 		return nil
 	})
@@ -101,18 +115,92 @@ func ParseDirectory(ctx context.Context, dir string, opts Options) (*Result, err
 	if err != nil {
 		return nil, err
 	}
-
-	// Change to the directory so relative paths work
-	origDir, err := os.Getwd()
-	if err != nil {
+	if opts.ImportPaths, err = importPaths(absDir, opts.ImportPaths); err != nil {
 		return nil, err
 	}
-	defer os.Chdir(origDir)
+	return ParseFiles(ctx, protoFiles, opts)
+}
 
-	if err := os.Chdir(absDir); err != nil {
-		return nil, err
+// ParsePaths parses a mix of proto files and directories, as given on the
+// command line. Directories are parsed with ParseDirectory; files are parsed
+// together relative to the first file's directory, which is also their
+// import path.
+func ParsePaths(ctx context.Context, paths []string, opts Options) (*Result, error) {
+	var files, dirs []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+		} else {
+			files = append(files, path)
+		}
 	}
 
-	opts.ImportPaths = []string{"."}
-	return ParseFiles(ctx, protoFiles, opts)
+	combined := &Result{}
+	for _, dir := range dirs {
+		result, err := ParseDirectory(ctx, dir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse directory %s: %w", dir, err)
+		}
+		combined.Files = append(combined.Files, result.Files...)
+		combined.Errors = append(combined.Errors, result.Errors...)
+	}
+
+	if len(files) > 0 {
+		baseNames := make([]string, len(files))
+		for i, f := range files {
+			baseNames[i] = filepath.Base(f)
+		}
+
+		absDir, err := filepath.Abs(filepath.Dir(files[0]))
+		if err != nil {
+			return nil, err
+		}
+		if opts.ImportPaths, err = importPaths(absDir, opts.ImportPaths); err != nil {
+			return nil, err
+		}
+		result, err := ParseFiles(ctx, baseNames, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse files: %w", err)
+		}
+		combined.Files = append(combined.Files, result.Files...)
+		combined.Errors = append(combined.Errors, result.Errors...)
+	}
+
+	return combined, nil
+}
+
+// importPaths returns root followed by the extra import paths, made
+// absolute so that they do not depend on the working directory.
+func importPaths(root string, extra []string) ([]string, error) {
+	paths := []string{root}
+	for _, p := range extra {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, abs)
+	}
+	return paths, nil
+}
+
+// included reports whether a file found in a directory, given by its
+// slash-separated path relative to the directory, matches Includes.
+func (o Options) included(rel string) bool {
+	if len(o.Includes) == 0 {
+		return true
+	}
+	for _, pattern := range o.Includes {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
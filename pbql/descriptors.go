@@ -0,0 +1,46 @@
+package pbql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Descriptor returns the loaded element with the given full name, or the id
+// used for it in the tables (enum_values.id is the enum's full name and the
+// value's name), or nil.
+func (ix *Index) Descriptor(fullName string) protoreflect.Descriptor {
+	return ix.db.Descriptor(fullName)
+}
+
+// QueryDescriptors runs a query and returns the descriptor named by each
+// row's full_name column, or its id column when there is none.
+func (ix *Index) QueryDescriptors(ctx context.Context, query string, args ...any) ([]protoreflect.Descriptor, error) {
+	if err := ix.db.CheckQuery(ctx, query); err != nil {
+		return nil, err
+	}
+	return ix.db.QueryDescriptors(ctx, query, args...)
+}
+
+// QueryDescriptorsAs runs QueryDescriptors and asserts each descriptor to T,
+// such as protoreflect.MessageDescriptor for a query on messages.
+//
+//	msgs, err := pbql.QueryDescriptorsAs[protoreflect.MessageDescriptor](ctx, ix,
+//		"SELECT full_name FROM messages WHERE file = ?", "users.proto")
+func QueryDescriptorsAs[T protoreflect.Descriptor](ctx context.Context, ix *Index, query string, args ...any) ([]T, error) {
+	descs, err := ix.QueryDescriptors(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, len(descs))
+	for i, desc := range descs {
+		d, ok := desc.(T)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a %s", desc.FullName(), reflect.TypeFor[T]())
+		}
+		results[i] = d
+	}
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package pbql
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestQueryDescriptors(t *testing.T) {
+	ctx := context.Background()
+	ix, err := Open(ctx, Config{Paths: []string{"../testdata/users.proto"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer ix.Close()
+
+	msgs, err := QueryDescriptorsAs[protoreflect.MessageDescriptor](ctx, ix, "SELECT full_name FROM messages WHERE name = 'User'")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Fields().ByName("email") == nil {
+		t.Errorf("Unexpected messages: %v", msgs)
+	}
+
+	values, err := ix.QueryDescriptors(ctx, "SELECT id FROM enum_values WHERE number = 1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(values) != 1 || values[0].Name() != "USER_STATUS_ACTIVE" {
+		t.Errorf("Unexpected enum values: %v", values)
+	}
+
+	if _, err := QueryDescriptorsAs[protoreflect.EnumDescriptor](ctx, ix, "SELECT full_name FROM messages"); err == nil {
+		t.Error("Expected an error for messages queried as enums")
+	}
+}
@@ -0,0 +1,192 @@
+// Package pbql indexes protobuf definitions in an embedded DuckDB database
+// and queries them with SQL, as the pbql-go command does.
+//
+//	ix, err := pbql.Open(ctx, pbql.Config{Paths: []string{"./protos"}})
+//	if err != nil {
+//		return err
+//	}
+//	defer ix.Close()
+//
+//	methods, err := pbql.QueryAs[pbql.Method](ctx, ix,
+//		"SELECT * FROM methods WHERE server_streaming")
+//
+// The tables are documented in the database itself: query
+// information_schema.columns for their columns and descriptions.
+package pbql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/connor15mcc/pbql-go/parser"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// Config configures an Index.
+type Config struct {
+	// Paths are the proto files and directories loaded by Open. Files in a
+	// directory are imported relative to it; files given directly are
+	// imported relative to the directory of the first one.
+	Paths []string
+	// ImportPaths are further directories to resolve imports from.
+	ImportPaths []string
+	// Includes limits the files loaded from directories to those matching
+	// one of these glob patterns, against their path relative to the
+	// directory, or their base name for patterns without a slash.
+	Includes []string
+	// Database is a DuckDB file to store the index in, so other tools can
+	// query it; empty keeps it in memory. It is not a cache: Open always
+	// parses the protos again and regenerates the proto tables, dropping
+	// their previous contents. Tables, views and macros created by users
+	// are kept.
+	Database string
+	// ReadOnly rejects queries other than SELECT statements and keeps
+	// DuckDB from accessing files or installing extensions, for indexes
+	// queried by untrusted users.
+	ReadOnly bool
+}
+
+// Option adjusts the Config passed to Open.
+type Option func(*Config)
+
+// WithImportPaths adds directories to resolve imports from.
+func WithImportPaths(paths ...string) Option {
+	return func(c *Config) {
+		c.ImportPaths = append(c.ImportPaths, paths...)
+	}
+}
+
+// WithIncludes adds glob patterns selecting the files loaded from
+// directories.
+func WithIncludes(patterns ...string) Option {
+	return func(c *Config) {
+		c.Includes = append(c.Includes, patterns...)
+	}
+}
+
+// WithDatabase stores the index in a DuckDB file instead of memory. The
+// proto tables in it are regenerated by every Open.
+func WithDatabase(path string) Option {
+	return func(c *Config) {
+		c.Database = path
+	}
+}
+
+// WithReadOnly only allows SELECT statements, and keeps DuckDB from
+// accessing files or installing extensions.
+func WithReadOnly() Option {
+	return func(c *Config) {
+		c.ReadOnly = true
+	}
+}
+
+// Index is a queryable database of protobuf definitions. It is safe for
+// concurrent use.
+type Index struct {
+	db      *schema.DB
+	options parser.Options
+	// mu serializes loads
+	mu sync.Mutex
+}
+
+// LoadError reports the files that could not be compiled. The files that
+// compiled are loaded regardless.
+type LoadError struct {
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e.Errors[0], len(e.Errors)-1)
+}
+
+func (e *LoadError) Unwrap() []error {
+	return e.Errors
+}
+
+// Open creates an index and loads cfg.Paths into it. When some files fail
+// to compile, Open returns the index with the others loaded, along with a
+// *LoadError.
+func Open(ctx context.Context, cfg Config, opts ...Option) (*Index, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	db, err := schema.Open(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReadOnly {
+		if err := db.SetReadOnly(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	ix := &Index{
+		db: db,
+		options: parser.Options{
+			ImportPaths: cfg.ImportPaths,
+			Includes:    cfg.Includes,
+		},
+	}
+
+	if len(cfg.Paths) == 0 {
+		return ix, nil
+	}
+	if err := ix.Load(ctx, cfg.Paths...); err != nil {
+		var loadErr *LoadError
+		if errors.As(err, &loadErr) {
+			return ix, err
+		}
+		db.Close()
+		return nil, err
+	}
+	return ix, nil
+}
+
+// Load parses proto files and directories and adds them to the index. Files
+// already loaded are skipped. When some files fail to compile, the others
+// are loaded and a *LoadError is returned.
+func (ix *Index) Load(ctx context.Context, paths ...string) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	result, err := parser.ParsePaths(ctx, paths, ix.options)
+	if err != nil {
+		return fmt.Errorf("failed to parse protos: %w", err)
+	}
+	if err := ix.db.LoadFiles(result.Files); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return &LoadError{Errors: result.Errors}
+	}
+	return nil
+}
+
+// Query runs a SQL query against the index. Arguments are bound to ? or $n
+// placeholders. In read-only mode, statements other than SELECT fail with
+// schema.ErrNotReadOnly.
+func (ix *Index) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := ix.db.CheckQuery(ctx, query); err != nil {
+		return nil, err
+	}
+	return ix.db.QueryContext(ctx, query, args...)
+}
+
+// DB returns the underlying database, e.g. to prepare statements or create
+// views and macros. Its queries are not checked in read-only mode, although
+// DuckDB's file access stays disabled.
+func (ix *Index) DB() *sql.DB {
+	return ix.db.DB
+}
+
+// Close releases the database. A database file is left in place.
+func (ix *Index) Close() error {
+	return ix.db.Close()
+}
@@ -0,0 +1,89 @@
+package pbql
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+func TestOpenAndLoad(t *testing.T) {
+	ctx := context.Background()
+	ix, err := Open(ctx, Config{Paths: []string{"../testdata"}}, WithIncludes("users.proto"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer ix.Close()
+
+	enums, err := QueryAs[Enum](ctx, ix, "SELECT * FROM enums WHERE file = ?", "users.proto")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(enums) == 0 || enums[0].File != "users.proto" || enums[0].FullName == "" {
+		t.Errorf("Unexpected enums: %+v", enums)
+	}
+
+	if err := ix.Load(ctx, "../testdata/orders.proto"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	type count struct {
+		File string
+		N    int
+	}
+	counts, err := QueryAs[count](ctx, ix, "SELECT file, count(*) AS n FROM messages GROUP BY file ORDER BY file")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(counts) != 2 || counts[0].File != "orders.proto" || counts[1].File != "users.proto" || counts[0].N == 0 {
+		t.Errorf("Unexpected counts: %+v", counts)
+	}
+}
+
+func TestOpenDatabase(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.db")
+	ix, err := Open(ctx, Config{Paths: []string{"../testdata"}}, WithDatabase(path))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ix.DB().Exec("CREATE VIEW service_names AS SELECT name FROM services"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ix.Close()
+
+	// The proto tables are regenerated from the given paths, while objects
+	// created by users are kept
+	ix, err = Open(ctx, Config{Paths: []string{"../testdata"}}, WithDatabase(path), WithIncludes("users.proto"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer ix.Close()
+	var files, services int
+	if err := ix.DB().QueryRow("SELECT (SELECT count(*) FROM files), (SELECT count(*) FROM service_names)").Scan(&files, &services); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if files != 1 || services == 0 {
+		t.Errorf("Expected only users.proto to be loaded and the view to be kept, got %d files and %d services", files, services)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	ix, err := Open(ctx, Config{Paths: []string{"../testdata/users.proto"}}, WithReadOnly())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer ix.Close()
+
+	if _, err := ix.Query(ctx, "DELETE FROM messages"); !errors.Is(err, schema.ErrNotReadOnly) {
+		t.Errorf("Expected ErrNotReadOnly, got: %v", err)
+	}
+	if err := ix.Load(ctx, "../testdata/orders.proto"); err != nil {
+		t.Errorf("Expected loading to still work, got: %v", err)
+	}
+	if _, err := QueryAs[Message](ctx, ix, "SELECT * FROM messages"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
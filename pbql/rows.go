@@ -0,0 +1,259 @@
+package pbql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// File is a row of the files table.
+type File struct {
+	Name     string         `pbql:"name"`
+	Package  string         `pbql:"package"`
+	Syntax   string         `pbql:"syntax"`
+	Edition  string         `pbql:"edition"`
+	Options  map[string]any `pbql:"options"`
+	Features map[string]any `pbql:"features"`
+}
+
+// Message is a row of the messages table.
+type Message struct {
+	FullName      string         `pbql:"full_name"`
+	Name          string         `pbql:"name"`
+	File          string         `pbql:"file"`
+	ParentMessage string         `pbql:"parent_message"`
+	IsMapEntry    bool           `pbql:"is_map_entry"`
+	IsDeprecated  bool           `pbql:"is_deprecated"`
+	Options       map[string]any `pbql:"options"`
+	Features      map[string]any `pbql:"features"`
+}
+
+// Field is a row of the fields table.
+type Field struct {
+	ID               string         `pbql:"id"`
+	Name             string         `pbql:"name"`
+	Number           int32          `pbql:"number"`
+	Message          string         `pbql:"message"`
+	Type             string         `pbql:"type"`
+	TypeName         string         `pbql:"type_name"`
+	Label            string         `pbql:"label"`
+	IsRepeated       bool           `pbql:"is_repeated"`
+	IsOptional       bool           `pbql:"is_optional"`
+	IsMap            bool           `pbql:"is_map"`
+	MapKeyType       string         `pbql:"map_key_type"`
+	MapValueType     string         `pbql:"map_value_type"`
+	DefaultValue     string         `pbql:"default_value"`
+	JSONName         string         `pbql:"json_name"`
+	HasPresence      bool           `pbql:"has_presence"`
+	IsPacked         bool           `pbql:"is_packed"`
+	IsDeprecated     bool           `pbql:"is_deprecated"`
+	IsGroup          bool           `pbql:"is_group"`
+	OneofName        string         `pbql:"oneof_name"`
+	IsSyntheticOneof bool           `pbql:"is_synthetic_oneof"`
+	JSType           string         `pbql:"jstype"`
+	CType            string         `pbql:"ctype"`
+	IsLazy           bool           `pbql:"is_lazy"`
+	Options          map[string]any `pbql:"options"`
+	Features         map[string]any `pbql:"features"`
+}
+
+// Range is an inclusive range of numbers, as in enums.reserved_ranges.
+type Range struct {
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+}
+
+// Enum is a row of the enums table.
+type Enum struct {
+	FullName       string         `pbql:"full_name"`
+	Name           string         `pbql:"name"`
+	File           string         `pbql:"file"`
+	ParentMessage  string         `pbql:"parent_message"`
+	IsClosed       bool           `pbql:"is_closed"`
+	AllowAlias     bool           `pbql:"allow_alias"`
+	ReservedNames  []string       `pbql:"reserved_names"`
+	ReservedRanges []Range        `pbql:"reserved_ranges"`
+	IsDeprecated   bool           `pbql:"is_deprecated"`
+	Options        map[string]any `pbql:"options"`
+	Features       map[string]any `pbql:"features"`
+}
+
+// EnumValue is a row of the enum_values table.
+type EnumValue struct {
+	ID           string         `pbql:"id"`
+	Name         string         `pbql:"name"`
+	Number       int32          `pbql:"number"`
+	Enum         string         `pbql:"enum"`
+	IsAliasOf    string         `pbql:"is_alias_of"`
+	IsDeprecated bool           `pbql:"is_deprecated"`
+	Options      map[string]any `pbql:"options"`
+}
+
+// Service is a row of the services table.
+type Service struct {
+	FullName     string         `pbql:"full_name"`
+	Name         string         `pbql:"name"`
+	File         string         `pbql:"file"`
+	IsDeprecated bool           `pbql:"is_deprecated"`
+	Options      map[string]any `pbql:"options"`
+}
+
+// Method is a row of the methods table.
+type Method struct {
+	FullName        string         `pbql:"full_name"`
+	Name            string         `pbql:"name"`
+	Service         string         `pbql:"service"`
+	InputType       string         `pbql:"input_type"`
+	OutputType      string         `pbql:"output_type"`
+	ClientStreaming bool           `pbql:"client_streaming"`
+	ServerStreaming bool           `pbql:"server_streaming"`
+	IsDeprecated    bool           `pbql:"is_deprecated"`
+	Options         map[string]any `pbql:"options"`
+}
+
+// Extension is a row of the extensions table.
+type Extension struct {
+	FullName     string         `pbql:"full_name"`
+	Name         string         `pbql:"name"`
+	Number       int32          `pbql:"number"`
+	File         string         `pbql:"file"`
+	Extendee     string         `pbql:"extendee"`
+	Type         string         `pbql:"type"`
+	TypeName     string         `pbql:"type_name"`
+	Label        string         `pbql:"label"`
+	HasPresence  bool           `pbql:"has_presence"`
+	IsPacked     bool           `pbql:"is_packed"`
+	IsDeprecated bool           `pbql:"is_deprecated"`
+	Options      map[string]any `pbql:"options"`
+}
+
+// QueryAs runs a query and scans its rows into values of T, as ScanRows
+// does.
+func QueryAs[T any](ctx context.Context, ix *Index, query string, args ...any) ([]T, error) {
+	rows, err := ix.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return ScanRows[T](rows)
+}
+
+// ScanRows scans every row into a value of T, a struct such as Message.
+// Columns are matched to fields by their pbql tag, or else by name ignoring
+// case and underscores (so json_name fills JSONName); columns without a
+// field are ignored and NULLs leave the zero value. JSON columns can be
+// scanned into any type encoding/json can decode them into.
+func ScanRows[T any](rows *sql.Rows) ([]T, error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot scan rows into %s: not a struct", typ)
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([][]int, len(cols))
+	for i, col := range cols {
+		fields[i] = fieldFor(typ, col)
+	}
+
+	results := []T{}
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		var row T
+		v := reflect.ValueOf(&row).Elem()
+		for i, index := range fields {
+			if index == nil {
+				continue
+			}
+			if err := assign(v.FieldByIndex(index), values[i]); err != nil {
+				return nil, fmt.Errorf("failed to scan column %s: %w", cols[i], err)
+			}
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// fieldFor returns the index of the exported field of typ that a column is
+// scanned into, or nil.
+func fieldFor(typ reflect.Type, col string) []int {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	}
+	var match []int
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		switch tag := f.Tag.Get("pbql"); {
+		case tag == "-":
+		case tag == col:
+			return f.Index
+		case tag == "" && match == nil && normalize(f.Name) == normalize(col):
+			match = f.Index
+		}
+	}
+	return match
+}
+
+// assign stores a scanned value in a field, converting between numeric
+// types and decoding JSON values into the field's type.
+func assign(dst reflect.Value, val any) error {
+	if val == nil {
+		dst.SetZero()
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer {
+		ptr := reflect.New(dst.Type().Elem())
+		if err := assign(ptr.Elem(), val); err != nil {
+			return err
+		}
+		dst.Set(ptr)
+		return nil
+	}
+
+	src := reflect.ValueOf(val)
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+		return nil
+	case isNumber(src.Kind()) && isNumber(dst.Kind()):
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	case src.Kind() == reflect.String && dst.Kind() == reflect.String:
+		dst.SetString(src.String())
+		return nil
+	}
+
+	// Lists and JSON values arrive as []any and map[string]any
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst.Addr().Interface()); err != nil {
+		return fmt.Errorf("cannot store %T in %s", val, dst.Type())
+	}
+	return nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package pgwire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxMessageSize bounds the messages accepted from clients.
+const maxMessageSize = 16 << 20
+
+// Codes of the startup packets, sent in place of a protocol version.
+const (
+	protocolVersion = 3 << 16
+	sslRequest      = 80877103
+	gssEncRequest   = 80877104
+	cancelRequest   = 80877102
+)
+
+var errShortMessage = errors.New("message too short")
+
+// readMessage reads a typed message: a type byte, then its length
+// (including itself) and body.
+func readMessage(r *bufio.Reader) (byte, []byte, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	body, err := readBody(r)
+	return typ, body, err
+}
+
+// readStartup reads a startup packet, which has no type byte.
+func readStartup(r *bufio.Reader) ([]byte, error) {
+	return readBody(r)
+}
+
+func readBody(r *bufio.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n < 4 || n > maxMessageSize {
+		return nil, fmt.Errorf("invalid message length %d", n)
+	}
+	body := make([]byte, n-4)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// reader decodes the fields of a message body. Reading past the end sets
+// err and returns zero values, so a message can be decoded in full before
+// checking for errors.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil || n < 0 || len(r.b) < n {
+		r.err = errShortMessage
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *reader) int16() int {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return int(int16(binary.BigEndian.Uint16(b)))
+}
+
+func (r *reader) int32() int {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return int(int32(binary.BigEndian.Uint32(b)))
+}
+
+// count reads the int16 length of an array, which cannot be negative.
+func (r *reader) count() int {
+	n := r.int16()
+	if n < 0 {
+		r.err = fmt.Errorf("invalid count %d", n)
+		return 0
+	}
+	return n
+}
+
+func (r *reader) byte() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// string reads a null-terminated string.
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.b {
+		if c == 0 {
+			s := string(r.b[:i])
+			r.b = r.b[i+1:]
+			return s
+		}
+	}
+	r.err = errShortMessage
+	return ""
+}
+
+// writer buffers outgoing messages until they are flushed.
+type writer struct {
+	w   io.Writer
+	buf []byte
+	// start is the offset of the current message's length
+	start int
+}
+
+// begin starts a message of the given type.
+func (w *writer) begin(typ byte) {
+	w.buf = append(w.buf, typ, 0, 0, 0, 0)
+	w.start = len(w.buf) - 4
+}
+
+// end fills in the length of the current message.
+func (w *writer) end() {
+	binary.BigEndian.PutUint32(w.buf[w.start:], uint32(len(w.buf)-w.start))
+}
+
+func (w *writer) int16(n int) {
+	w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+}
+
+func (w *writer) int32(n int) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+}
+
+func (w *writer) byte(c byte) {
+	w.buf = append(w.buf, c)
+}
+
+// string writes a null-terminated string.
+func (w *writer) string(s string) {
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+}
+
+// value writes a length-prefixed field value; nil is SQL NULL.
+func (w *writer) value(b []byte) {
+	if b == nil {
+		w.int32(-1)
+		return
+	}
+	w.int32(len(b))
+	w.buf = append(w.buf, b...)
+}
+
+// flush sends the buffered messages.
+func (w *writer) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+// empty writes a message without a body.
+func (w *writer) empty(typ byte) {
+	w.begin(typ)
+	w.end()
+}
+
+// errorResponse writes an ErrorResponse with a severity (ERROR, or FATAL
+// before closing the connection), the error's SQLSTATE code and message.
+func (w *writer) errorResponse(severity string, err error) {
+	w.begin('E')
+	w.byte('S')
+	w.string(severity)
+	w.byte('V')
+	w.string(severity)
+	w.byte('C')
+	w.string(errorCode(err))
+	w.byte('M')
+	w.string(err.Error())
+	w.byte(0)
+	w.end()
+}
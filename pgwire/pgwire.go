@@ -0,0 +1,280 @@
+// Package pgwire serves a loaded schema over the PostgreSQL wire protocol,
+// so that psql and SQL tools such as Grafana, Metabase and DBeaver can
+// query it as if it were a Postgres database.
+//
+// Both the simple and extended query protocols are supported, with values
+// in text format. Queries run on DuckDB, so they use DuckDB's SQL dialect
+// rather than Postgres's, and the pg_catalog introspection some tools rely
+// on is limited to what DuckDB provides. Only SELECT statements are
+// accepted; session commands (SET, BEGIN, ...) are acknowledged without
+// effect. There is no authentication or TLS: every client is trusted, so
+// bind to a local or otherwise protected address.
+package pgwire
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// ServerVersion is the PostgreSQL version reported to clients, which some
+// use to pick the features and catalog queries they use.
+const ServerVersion = "15.0"
+
+// Options configures the server.
+type Options struct {
+	// Timeout cancels queries running longer than this; 0 means no limit
+	Timeout time.Duration
+}
+
+// Server serves a schema to PostgreSQL clients.
+type Server struct {
+	db   *schema.DB
+	opts Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners map[net.Listener]bool
+	// conns are the open connections, from when they are accepted, so that
+	// Close also ends those still starting up
+	conns    map[net.Conn]bool
+	sessions map[int]*session
+	nextPID  int
+	wg       sync.WaitGroup
+}
+
+// New returns a server for a loaded schema. The database should also be made
+// read-only with schema.DB.SetReadOnly, to keep queries away from files.
+func New(db *schema.DB, opts Options) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		db:        db,
+		opts:      opts,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]bool),
+		conns:     make(map[net.Conn]bool),
+		sessions:  make(map[int]*session),
+	}
+}
+
+// ErrServerClosed is returned by Serve after Close.
+var ErrServerClosed = errors.New("pgwire: server closed")
+
+// Serve accepts connections on listener until Close, handling each one in
+// its own goroutine. It always returns a non-nil error.
+func (srv *Server) Serve(listener net.Listener) error {
+	srv.mu.Lock()
+	if srv.ctx.Err() != nil {
+		srv.mu.Unlock()
+		return ErrServerClosed
+	}
+	srv.listeners[listener] = true
+	srv.mu.Unlock()
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.listeners, listener)
+		srv.mu.Unlock()
+	}()
+
+	for {
+		nc, err := listener.Accept()
+		if err != nil {
+			if srv.ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !srv.track(nc) {
+			nc.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			defer srv.wg.Done()
+			defer srv.untrack(nc)
+			if err := srv.handle(nc); err != nil {
+				slog.Debug("pgwire connection closed", "remote", nc.RemoteAddr(), "error", err)
+			}
+		}()
+	}
+}
+
+// Close stops the listeners, cancels running queries and closes all
+// connections, waiting for their sessions to end.
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	srv.cancel()
+	for l := range srv.listeners {
+		l.Close()
+	}
+	for nc := range srv.conns {
+		nc.Close()
+	}
+	srv.mu.Unlock()
+	srv.wg.Wait()
+	return nil
+}
+
+// track records an accepted connection, unless the server is closed.
+func (srv *Server) track(nc net.Conn) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.ctx.Err() != nil {
+		return false
+	}
+	srv.conns[nc] = true
+	srv.wg.Add(1)
+	return true
+}
+
+func (srv *Server) untrack(nc net.Conn) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	delete(srv.conns, nc)
+}
+
+// handle runs a connection, from its startup packet until it closes.
+func (srv *Server) handle(nc net.Conn) error {
+	defer nc.Close()
+	s := &session{srv: srv, nc: nc, r: bufio.NewReader(nc), w: writer{w: nc}}
+
+	params, err := s.startup()
+	if err != nil || params == nil {
+		return err
+	}
+
+	conn, err := srv.db.Conn(srv.ctx)
+	if err != nil {
+		s.w.errorResponse("FATAL", err)
+		s.w.flush()
+		return err
+	}
+	defer conn.Close()
+	s.conn = conn
+
+	if err := srv.register(s); err != nil {
+		return err
+	}
+	defer srv.unregister(s)
+
+	s.w.begin('R')
+	s.w.int32(0) // AuthenticationOk
+	s.w.end()
+	for _, p := range [][2]string{
+		{"server_version", ServerVersion},
+		{"server_encoding", "UTF8"},
+		{"client_encoding", "UTF8"},
+		{"DateStyle", "ISO, MDY"},
+		{"TimeZone", "UTC"},
+		{"integer_datetimes", "on"},
+		{"standard_conforming_strings", "on"},
+		{"application_name", params["application_name"]},
+	} {
+		s.w.begin('S')
+		s.w.string(p[0])
+		s.w.string(p[1])
+		s.w.end()
+	}
+	s.w.begin('K')
+	s.w.int32(s.pid)
+	s.w.int32(s.secret)
+	s.w.end()
+	s.ready()
+	if err := s.w.flush(); err != nil {
+		return err
+	}
+
+	slog.Debug("pgwire session started", "remote", nc.RemoteAddr(), "user", params["user"], "application", params["application_name"])
+	return s.run(srv.ctx)
+}
+
+// startup reads startup packets until the one starting a session, returning
+// its parameters. Encryption requests are declined, and a cancel request
+// (sent on a connection of its own) returns no parameters.
+func (s *session) startup() (map[string]string, error) {
+	for {
+		body, err := readStartup(s.r)
+		if err != nil {
+			return nil, err
+		}
+		r := &reader{b: body}
+		switch code := r.int32(); code {
+		case sslRequest, gssEncRequest:
+			if _, err := s.nc.Write([]byte{'N'}); err != nil {
+				return nil, err
+			}
+		case cancelRequest:
+			pid, secret := r.int32(), r.int32()
+			if r.err == nil {
+				s.srv.cancelQuery(pid, secret)
+			}
+			return nil, nil
+		case protocolVersion:
+			params := make(map[string]string)
+			for {
+				key := r.string()
+				if key == "" || r.err != nil {
+					break
+				}
+				params[key] = r.string()
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+			return params, nil
+		default:
+			err := &pgError{codeFeatureNotSupported, fmt.Sprintf("unsupported frontend protocol %d.%d: server supports 3.0", code>>16, code&0xffff)}
+			s.w.errorResponse("FATAL", err)
+			s.w.flush()
+			return nil, err
+		}
+	}
+}
+
+// register assigns a session its process ID and secret key, which identify
+// it in cancel requests.
+func (srv *Server) register(s *session) error {
+	var key [4]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return err
+	}
+	s.secret = int(int32(binary.BigEndian.Uint32(key[:])))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.ctx.Err() != nil {
+		return ErrServerClosed
+	}
+	srv.nextPID++
+	s.pid = srv.nextPID
+	srv.sessions[s.pid] = s
+	return nil
+}
+
+func (srv *Server) unregister(s *session) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	delete(srv.sessions, s.pid)
+}
+
+// cancelQuery cancels the running query of the session with a process ID, if
+// the secret key matches.
+func (srv *Server) cancelQuery(pid, secret int) {
+	srv.mu.Lock()
+	s := srv.sessions[pid]
+	srv.mu.Unlock()
+	if s != nil && s.secret == secret {
+		s.cancelQuery()
+	}
+}
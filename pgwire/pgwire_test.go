@@ -0,0 +1,282 @@
+package pgwire
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/connor15mcc/pbql-go/parser"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// testDB loads the testdata protos in read-only mode, as serve does.
+func testDB(t *testing.T) *schema.DB {
+	t.Helper()
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	result, err := parser.ParsePaths(context.Background(), []string{"../testdata"}, parser.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.LoadFiles(result.Files); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.SetReadOnly(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return db
+}
+
+// pgConn is a minimal PostgreSQL protocol client, for testing the pgwire
+// server without a driver.
+type pgConn struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+type pgMessage struct {
+	typ  byte
+	body []byte
+}
+
+// testConn serves the testdata protos over the Postgres protocol and
+// connects to them.
+func testConn(t *testing.T) *pgConn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	srv := New(testDB(t), Options{})
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	c := &pgConn{t: t, conn: conn, r: bufio.NewReader(conn)}
+
+	startup := binary.BigEndian.AppendUint32(nil, 3<<16)
+	startup = append(startup, "user\x00test\x00\x00"...)
+	conn.Write(binary.BigEndian.AppendUint32(nil, uint32(len(startup)+4)))
+	conn.Write(startup)
+	msgs := c.receive()
+	if msgs[0].typ != 'R' || !slices.ContainsFunc(msgs, func(m pgMessage) bool { return m.typ == 'K' }) {
+		t.Fatalf("Unexpected startup messages: %v", msgs)
+	}
+	return c
+}
+
+// send sends a message of fields: strings are null-terminated, ints are
+// int16 and [][]byte are int32 length-prefixed values.
+func (c *pgConn) send(typ byte, fields ...any) {
+	body := []byte{}
+	for _, f := range fields {
+		switch f := f.(type) {
+		case string:
+			body = append(append(body, f...), 0)
+		case byte:
+			body = append(body, f)
+		case int:
+			body = binary.BigEndian.AppendUint16(body, uint16(f))
+		case [][]byte:
+			for _, v := range f {
+				body = binary.BigEndian.AppendUint32(body, uint32(len(v)))
+				body = append(body, v...)
+			}
+		}
+	}
+	msg := append([]byte{typ}, binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	if _, err := c.conn.Write(append(msg, body...)); err != nil {
+		c.t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+// receive reads messages up to and including ReadyForQuery.
+func (c *pgConn) receive() []pgMessage {
+	c.t.Helper()
+	var msgs []pgMessage
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(c.r, header[:]); err != nil {
+			c.t.Fatalf("Unexpected error after %v: %v", msgs, err)
+		}
+		body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+		if _, err := io.ReadFull(c.r, body); err != nil {
+			c.t.Fatalf("Unexpected error: %v", err)
+		}
+		msgs = append(msgs, pgMessage{header[0], body})
+		if header[0] == 'Z' {
+			return msgs
+		}
+	}
+}
+
+// pgTypes lists the message types, as a string.
+func pgTypes(msgs []pgMessage) string {
+	var b strings.Builder
+	for _, m := range msgs {
+		b.WriteByte(m.typ)
+	}
+	return b.String()
+}
+
+// pgRows decodes the DataRows of messages.
+func pgRows(msgs []pgMessage) [][]string {
+	var rows [][]string
+	for _, m := range msgs {
+		if m.typ != 'D' {
+			continue
+		}
+		var row []string
+		b := m.body[2:]
+		for range binary.BigEndian.Uint16(m.body) {
+			n := int32(binary.BigEndian.Uint32(b))
+			b = b[4:]
+			if n < 0 {
+				row = append(row, "NULL")
+				continue
+			}
+			row = append(row, string(b[:n]))
+			b = b[n:]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// pgFields returns the null-terminated strings of the messages of a type,
+// such as the tags of CommandComplete or the fields of an ErrorResponse.
+func pgFields(msgs []pgMessage, typ byte) []string {
+	var fields []string
+	for _, m := range msgs {
+		if m.typ == typ {
+			fields = append(fields, strings.Split(strings.TrimRight(string(m.body), "\x00"), "\x00")...)
+		}
+	}
+	return fields
+}
+
+func TestSimpleQuery(t *testing.T) {
+	c := testConn(t)
+
+	c.send('Q', "SET application_name = 'test'; SELECT name, 1 AS n FROM services ORDER BY name LIMIT 2")
+	msgs := c.receive()
+	if got := pgTypes(msgs); got != "CTDDCZ" {
+		t.Fatalf("Unexpected messages %q", got)
+	}
+	if tags := pgFields(msgs, 'C'); !slices.Equal(tags, []string{"SET", "SELECT 2"}) {
+		t.Errorf("Unexpected command tags %q", tags)
+	}
+	if !bytes.HasPrefix(msgs[1].body, []byte("\x00\x02name\x00")) {
+		t.Errorf("Unexpected row description %q", msgs[1].body)
+	}
+	if rows := pgRows(msgs); !slices.EqualFunc(rows, [][]string{{"AdminService", "1"}, {"InventoryService", "1"}}, slices.Equal) {
+		t.Errorf("Unexpected rows %q", rows)
+	}
+
+	c.send('Q', " ")
+	if got := pgTypes(c.receive()); got != "IZ" {
+		t.Errorf("Expected an empty query response, got %q", got)
+	}
+
+	c.send('Q', "SELECT * FROM no_such_table")
+	msgs = c.receive()
+	if fields := pgFields(msgs, 'E'); pgTypes(msgs) != "EZ" || !slices.Contains(fields, "C42P01") {
+		t.Errorf("Unexpected error response %q", fields)
+	}
+}
+
+func TestExtendedQuery(t *testing.T) {
+	c := testConn(t)
+
+	c.send('P', "byname", "SELECT name, full_name FROM services WHERE name = $1", 0)
+	c.send('D', byte('S'), "byname")
+	c.send('B', "", "byname", 0, 1, [][]byte{[]byte("AdminService")}, 0)
+	c.send('E', "", 0, 0)
+	c.send('S')
+	msgs := c.receive()
+	if got := pgTypes(msgs); got != "1tT2DCZ" {
+		t.Fatalf("Unexpected messages %q", got)
+	}
+	if params := msgs[1].body; !bytes.Equal(params, []byte{0, 1, 0, 0, 0, 25}) {
+		t.Errorf("Expected one text parameter, got %v", params)
+	}
+	if rows := pgRows(msgs); len(rows) != 1 || rows[0][0] != "AdminService" || !strings.HasSuffix(rows[0][1], ".AdminService") {
+		t.Errorf("Unexpected rows %q", rows)
+	}
+
+	// The statement can be bound again
+	c.send('B', "", "byname", 0, 1, [][]byte{[]byte("NoSuchService")}, 0)
+	c.send('E', "", 0, 0)
+	c.send('S')
+	if msgs := c.receive(); pgTypes(msgs) != "2CZ" || pgFields(msgs, 'C')[0] != "SELECT 0" {
+		t.Errorf("Unexpected messages %q", pgTypes(msgs))
+	}
+}
+
+func TestCloseDuringStartup(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	srv := New(testDB(t), Options{})
+	go srv.Serve(listener)
+
+	// A client that never sends its startup packet must not block Close
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	conn.Read(make([]byte, 1))
+
+	closed := make(chan struct{})
+	go func() {
+		srv.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Close blocked on a connection in startup")
+	}
+}
+
+func TestRejectsWrites(t *testing.T) {
+	c := testConn(t)
+
+	c.send('Q', "DROP TABLE fields")
+	if fields := pgFields(c.receive(), 'E'); !slices.Contains(fields, "C25006") {
+		t.Errorf("Expected a read-only error, got %q", fields)
+	}
+
+	// After an error, messages are skipped until Sync
+	c.send('P', "", "DELETE FROM fields", 0)
+	c.send('B', "", "", 0, 0, 0)
+	c.send('E', "", 0, 0)
+	c.send('S')
+	if msgs := c.receive(); pgTypes(msgs) != "EZ" || !slices.Contains(pgFields(msgs, 'E'), "C25006") {
+		t.Errorf("Unexpected messages %q", pgTypes(msgs))
+	}
+
+	c.send('Q', "SELECT count(*) FROM fields")
+	if rows := pgRows(c.receive()); len(rows) != 1 || rows[0][0] == "0" {
+		t.Errorf("Expected the fields to still be loaded, got %q", rows)
+	}
+}
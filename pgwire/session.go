@@ -0,0 +1,505 @@
+package pgwire
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/connor15mcc/pbql-go/output"
+	"github.com/connor15mcc/pbql-go/schema"
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// Transaction states reported in ReadyForQuery.
+const (
+	txIdle   = 'I'
+	txActive = 'T'
+	txFailed = 'E'
+)
+
+// sessionCommands are statements that only change session state in
+// PostgreSQL. Clients send them on their own (SET application_name,
+// BEGIN, ...), so they are acknowledged with their command tag without
+// being run; nothing else can change the read-only database.
+var sessionCommands = map[string]string{
+	"SET":        "SET",
+	"RESET":      "RESET",
+	"BEGIN":      "BEGIN",
+	"START":      "START TRANSACTION",
+	"COMMIT":     "COMMIT",
+	"END":        "COMMIT",
+	"ROLLBACK":   "ROLLBACK",
+	"ABORT":      "ROLLBACK",
+	"DISCARD":    "DISCARD ALL",
+	"DEALLOCATE": "DEALLOCATE",
+	"SAVEPOINT":  "SAVEPOINT",
+	"RELEASE":    "RELEASE",
+	"UNLISTEN":   "UNLISTEN",
+}
+
+// statement is a parsed statement: a query with the types of its
+// parameters and result columns, or a session command.
+type statement struct {
+	query   string
+	params  []duckdb.Type
+	columns []column
+	// command is the tag of a session command, which is not run
+	command string
+	// empty is set for a query with no statement in it
+	empty bool
+}
+
+// portal is a statement bound to its parameters, ready to execute.
+type portal struct {
+	stmt *statement
+	args []any
+}
+
+// session is one client connection.
+type session struct {
+	srv    *Server
+	nc     net.Conn
+	r      *bufio.Reader
+	w      writer
+	conn   *sql.Conn
+	pid    int
+	secret int
+
+	stmts   map[string]*statement
+	portals map[string]*portal
+	tx      byte
+	// skipping discards extended query messages after an error, up to the
+	// next Sync
+	skipping bool
+
+	// cancel interrupts the running query, for cancel requests
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// run handles the session after startup, until the client terminates or
+// the connection fails.
+func (s *session) run(ctx context.Context) error {
+	s.stmts = make(map[string]*statement)
+	s.portals = make(map[string]*portal)
+	s.tx = txIdle
+
+	for {
+		typ, body, err := readMessage(s.r)
+		if err != nil {
+			return err
+		}
+		if s.skipping && typ != 'S' {
+			continue
+		}
+
+		r := &reader{b: body}
+		switch typ {
+		case 'Q':
+			s.simpleQuery(ctx, r.string())
+		case 'P':
+			err = s.parse(ctx, r)
+		case 'B':
+			err = s.bind(r)
+		case 'D':
+			err = s.describe(r)
+		case 'E':
+			err = s.execute(ctx, r)
+		case 'C':
+			err = s.close(r)
+		case 'S':
+			s.skipping = false
+			s.ready()
+		case 'H':
+			// Flush: sent below
+		case 'X':
+			return nil
+		default:
+			err = &pgError{codeProtocolViolation, fmt.Sprintf("unsupported message type %q", typ)}
+		}
+		if err != nil {
+			s.error(err)
+			s.skipping = typ != 'Q'
+		}
+		if typ == 'Q' || typ == 'S' || typ == 'H' || len(s.w.buf) > 64<<10 {
+			if err := s.w.flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ready tells the client the session is ready for the next query.
+func (s *session) ready() {
+	s.w.begin('Z')
+	s.w.byte(s.tx)
+	s.w.end()
+}
+
+// error sends an ErrorResponse. Errors inside a transaction block mark it
+// failed until it ends, as in PostgreSQL.
+func (s *session) error(err error) {
+	if s.tx == txActive {
+		s.tx = txFailed
+	}
+	s.w.errorResponse("ERROR", err)
+}
+
+// simpleQuery runs each statement of a query, stopping at the first error.
+func (s *session) simpleQuery(ctx context.Context, query string) {
+	stmts := output.Split(query)
+	if len(stmts) == 0 {
+		s.w.empty('I')
+		s.ready()
+		return
+	}
+	for _, st := range stmts {
+		stmt, err := s.prepare(ctx, st.SQL)
+		if err == nil {
+			s.rowDescription(stmt)
+			err = s.run1(ctx, &portal{stmt: stmt})
+		}
+		if err != nil {
+			s.error(err)
+			break
+		}
+	}
+	s.ready()
+}
+
+// prepare checks and describes a single statement. Statements other than
+// SELECT are rejected, except for session commands, which are not run.
+func (s *session) prepare(ctx context.Context, query string) (*statement, error) {
+	stmts := output.Split(query)
+	switch {
+	case len(stmts) == 0:
+		return &statement{query: query, empty: true}, nil
+	case len(stmts) > 1:
+		return nil, &pgError{codeSyntaxError, "cannot insert multiple commands into a prepared statement"}
+	}
+	query = stmts[0].SQL
+
+	keyword := query
+	if end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		keyword = query[:end]
+	}
+	if tag, ok := sessionCommands[strings.ToUpper(keyword)]; ok {
+		return &statement{query: query, command: tag}, nil
+	}
+	if err := schema.CheckReadOnly(ctx, s.srv.db.DB, query); err != nil {
+		return nil, err
+	}
+
+	stmt := &statement{query: query}
+	err := s.conn.Raw(func(dc any) error {
+		prepared, err := dc.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer prepared.Close()
+		ds := prepared.(*duckdb.Stmt)
+
+		for i := 1; i <= ds.NumInput(); i++ {
+			t, err := ds.ParamType(i)
+			if err != nil {
+				return err
+			}
+			stmt.params = append(stmt.params, t)
+		}
+		n, err := ds.ColumnCount()
+		if err != nil {
+			return err
+		}
+		for i := range n {
+			name, err := ds.ColumnName(i)
+			if err != nil {
+				return err
+			}
+			t, err := ds.ColumnType(i)
+			if err != nil {
+				return err
+			}
+			stmt.columns = append(stmt.columns, column{name: name, oid: typeOID(t)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// sessionCommand tracks the transaction state for transaction commands.
+func (s *session) sessionCommand(tag string) {
+	switch tag {
+	case "BEGIN", "START TRANSACTION":
+		s.tx = txActive
+	case "COMMIT", "ROLLBACK":
+		s.tx = txIdle
+	}
+}
+
+// parse handles Parse: name, query and parameter types, which are taken
+// from DuckDB instead.
+func (s *session) parse(ctx context.Context, r *reader) error {
+	name, query := r.string(), r.string()
+	if r.err != nil {
+		return &pgError{codeProtocolViolation, r.err.Error()}
+	}
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+	s.stmts[name] = stmt
+	s.w.empty('1')
+	return nil
+}
+
+// bind handles Bind, creating a portal from a statement and parameters in
+// text format. Results can only be sent in text format.
+func (s *session) bind(r *reader) error {
+	portalName, stmtName := r.string(), r.string()
+	formats := make([]int, r.count())
+	for i := range formats {
+		formats[i] = r.int16()
+	}
+	values := make([][]byte, r.count())
+	for i := range values {
+		if n := r.int32(); n >= 0 {
+			values[i] = r.next(n)
+			if values[i] == nil {
+				values[i] = []byte{}
+			}
+		}
+	}
+	resultFormats := make([]int, r.count())
+	for i := range resultFormats {
+		resultFormats[i] = r.int16()
+	}
+	if r.err != nil {
+		return &pgError{codeProtocolViolation, r.err.Error()}
+	}
+
+	stmt, ok := s.stmts[stmtName]
+	if !ok {
+		return &pgError{"26000", fmt.Sprintf("prepared statement %q does not exist", stmtName)}
+	}
+	for _, f := range append(formats, resultFormats...) {
+		if f != 0 {
+			return &pgError{codeFeatureNotSupported, "only the text format is supported"}
+		}
+	}
+	if len(values) != len(stmt.params) && stmt.command == "" {
+		return &pgError{codeProtocolViolation, fmt.Sprintf("bind message supplies %d parameters, but prepared statement requires %d", len(values), len(stmt.params))}
+	}
+
+	p := &portal{stmt: stmt}
+	for i, v := range values {
+		if stmt.command != "" {
+			break
+		}
+		arg, err := decode(v, stmt.params[i])
+		if err != nil {
+			return &pgError{codeInvalidParameter, fmt.Sprintf("parameter $%d: %v", i+1, err)}
+		}
+		p.args = append(p.args, arg)
+	}
+	s.portals[portalName] = p
+	s.w.empty('2')
+	return nil
+}
+
+// describe handles Describe of a statement (its parameters and columns) or
+// of a portal (its columns).
+func (s *session) describe(r *reader) error {
+	kind, name := r.byte(), r.string()
+	if r.err != nil {
+		return &pgError{codeProtocolViolation, r.err.Error()}
+	}
+
+	switch kind {
+	case 'S':
+		stmt, ok := s.stmts[name]
+		if !ok {
+			return &pgError{"26000", fmt.Sprintf("prepared statement %q does not exist", name)}
+		}
+		s.w.begin('t')
+		s.w.int16(len(stmt.params))
+		for _, t := range stmt.params {
+			s.w.int32(typeOID(t))
+		}
+		s.w.end()
+		s.rowDescription(stmt)
+	case 'P':
+		p, ok := s.portals[name]
+		if !ok {
+			return &pgError{"34000", fmt.Sprintf("portal %q does not exist", name)}
+		}
+		s.rowDescription(p.stmt)
+	default:
+		return &pgError{codeProtocolViolation, fmt.Sprintf("invalid describe kind %q", kind)}
+	}
+	return nil
+}
+
+// rowDescription describes a statement's result columns, or sends NoData.
+func (s *session) rowDescription(stmt *statement) {
+	if len(stmt.columns) == 0 {
+		if stmt.command == "" && !stmt.empty {
+			// DuckDB statements always return rows, if only a count
+			s.w.empty('n')
+		}
+		return
+	}
+	s.w.begin('T')
+	s.w.int16(len(stmt.columns))
+	for _, c := range stmt.columns {
+		s.w.string(c.name)
+		s.w.int32(0) // table OID
+		s.w.int16(0) // column number
+		s.w.int32(c.oid)
+		s.w.int16(typeSize(c.oid))
+		s.w.int32(-1) // type modifier
+		s.w.int16(0)  // text format
+	}
+	s.w.end()
+}
+
+// execute handles Execute. The row limit is ignored: all rows are sent.
+func (s *session) execute(ctx context.Context, r *reader) error {
+	name := r.string()
+	r.int32()
+	if r.err != nil {
+		return &pgError{codeProtocolViolation, r.err.Error()}
+	}
+	p, ok := s.portals[name]
+	if !ok {
+		return &pgError{"34000", fmt.Sprintf("portal %q does not exist", name)}
+	}
+	return s.run1(ctx, p)
+}
+
+// close handles Close of a statement or portal.
+func (s *session) close(r *reader) error {
+	kind, name := r.byte(), r.string()
+	if r.err != nil {
+		return &pgError{codeProtocolViolation, r.err.Error()}
+	}
+	switch kind {
+	case 'S':
+		delete(s.stmts, name)
+	case 'P':
+		delete(s.portals, name)
+	}
+	s.w.empty('3')
+	return nil
+}
+
+// run1 executes a portal, sending its rows and CommandComplete.
+func (s *session) run1(ctx context.Context, p *portal) error {
+	switch {
+	case p.stmt.empty:
+		s.w.empty('I')
+		return nil
+	case p.stmt.command != "":
+		s.sessionCommand(p.stmt.command)
+		s.commandComplete(p.stmt.command)
+		return nil
+	}
+
+	var cancel context.CancelFunc
+	if s.srv.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.srv.opts.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.cancel = nil
+		s.mu.Unlock()
+	}()
+
+	rows, err := s.conn.QueryContext(ctx, p.stmt.query, p.args...)
+	if err != nil {
+		return s.queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	oids := make([]int, len(cols))
+	for i := range oids {
+		oids[i] = oidText
+		if i < len(p.stmt.columns) {
+			oids[i] = p.stmt.columns[i].oid
+		}
+	}
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		s.w.begin('D')
+		s.w.int16(len(values))
+		for i, v := range values {
+			s.w.value(encode(v, oids[i]))
+		}
+		s.w.end()
+		n++
+		if len(s.w.buf) > 64<<10 {
+			if err := s.w.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return s.queryError(ctx, err)
+	}
+	s.commandComplete(fmt.Sprintf("SELECT %d", n))
+	return nil
+}
+
+// queryError reports cancelled and timed out queries as such.
+func (s *session) queryError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &pgError{codeQueryCanceled, fmt.Sprintf("canceling statement due to statement timeout (%s)", s.srv.opts.Timeout)}
+	case errors.Is(ctx.Err(), context.Canceled):
+		return &pgError{codeQueryCanceled, "canceling statement due to user request"}
+	}
+	return err
+}
+
+func (s *session) commandComplete(tag string) {
+	s.w.begin('C')
+	s.w.string(tag)
+	s.w.end()
+}
+
+// cancelQuery interrupts the running query, if any.
+func (s *session) cancelQuery() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
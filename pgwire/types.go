@@ -0,0 +1,217 @@
+package pgwire
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/connor15mcc/pbql-go/schema"
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// PostgreSQL type OIDs, from pg_type.
+const (
+	oidBool        = 16
+	oidBytea       = 17
+	oidInt8        = 20
+	oidInt2        = 21
+	oidInt4        = 23
+	oidText        = 25
+	oidJSON        = 114
+	oidFloat4      = 700
+	oidFloat8      = 701
+	oidUnknown     = 705
+	oidDate        = 1082
+	oidTime        = 1083
+	oidTimestamp   = 1114
+	oidTimestampTZ = 1184
+	oidNumeric     = 1700
+	oidUUID        = 2950
+)
+
+// column is a result column, described to clients by name and type.
+type column struct {
+	name string
+	oid  int
+}
+
+// typeOID maps a DuckDB type to the PostgreSQL type its values are sent as.
+// Nested types are sent as JSON, and types without a close equivalent as
+// text.
+func typeOID(t duckdb.Type) int {
+	switch t {
+	case duckdb.TYPE_BOOLEAN:
+		return oidBool
+	case duckdb.TYPE_TINYINT, duckdb.TYPE_UTINYINT, duckdb.TYPE_SMALLINT:
+		return oidInt2
+	case duckdb.TYPE_USMALLINT, duckdb.TYPE_INTEGER:
+		return oidInt4
+	case duckdb.TYPE_UINTEGER, duckdb.TYPE_BIGINT:
+		return oidInt8
+	case duckdb.TYPE_UBIGINT, duckdb.TYPE_HUGEINT, duckdb.TYPE_UHUGEINT, duckdb.TYPE_DECIMAL, duckdb.TYPE_BIGNUM:
+		return oidNumeric
+	case duckdb.TYPE_FLOAT:
+		return oidFloat4
+	case duckdb.TYPE_DOUBLE:
+		return oidFloat8
+	case duckdb.TYPE_DATE:
+		return oidDate
+	case duckdb.TYPE_TIME:
+		return oidTime
+	case duckdb.TYPE_TIMESTAMP, duckdb.TYPE_TIMESTAMP_S, duckdb.TYPE_TIMESTAMP_MS, duckdb.TYPE_TIMESTAMP_NS:
+		return oidTimestamp
+	case duckdb.TYPE_TIMESTAMP_TZ:
+		return oidTimestampTZ
+	case duckdb.TYPE_BLOB:
+		return oidBytea
+	case duckdb.TYPE_UUID:
+		return oidUUID
+	case duckdb.TYPE_LIST, duckdb.TYPE_ARRAY, duckdb.TYPE_STRUCT, duckdb.TYPE_MAP, duckdb.TYPE_UNION:
+		return oidJSON
+	}
+	return oidText
+}
+
+// typeSize is the pg_type.typlen of a type, -1 for variable length.
+func typeSize(oid int) int {
+	switch oid {
+	case oidBool:
+		return 1
+	case oidInt2:
+		return 2
+	case oidInt4, oidFloat4, oidDate:
+		return 4
+	case oidInt8, oidFloat8, oidTime, oidTimestamp, oidTimestampTZ:
+		return 8
+	case oidUUID:
+		return 16
+	}
+	return -1
+}
+
+// encode formats a scanned value in PostgreSQL's text format for a column
+// of the given type. NULL is nil.
+func encode(val any, oid int) []byte {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case bool:
+		if v {
+			return []byte("t")
+		}
+		return []byte("f")
+	case string:
+		return []byte(v)
+	case []byte:
+		if oid == oidUUID && len(v) == 16 {
+			return []byte(fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16]))
+		}
+		return []byte(`\x` + hex.EncodeToString(v))
+	case int8, int16, int32, int64, int, uint8, uint16, uint32, uint64, uint:
+		return fmt.Appendf(nil, "%d", v)
+	case float32:
+		return strconv.AppendFloat(nil, float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(nil, v, 'g', -1, 64)
+	case *big.Int:
+		return []byte(v.String())
+	case time.Time:
+		switch oid {
+		case oidDate:
+			return []byte(v.Format("2006-01-02"))
+		case oidTime:
+			return []byte(v.Format("15:04:05.999999"))
+		case oidTimestampTZ:
+			return []byte(v.Format("2006-01-02 15:04:05.999999-07:00"))
+		}
+		return []byte(v.Format("2006-01-02 15:04:05.999999"))
+	case fmt.Stringer:
+		return []byte(v.String())
+	}
+	if data, err := json.Marshal(val); err == nil {
+		return data
+	}
+	return fmt.Appendf(nil, "%v", val)
+}
+
+// decode converts a parameter sent in text format to the Go value bound for
+// a DuckDB parameter of type t.
+func decode(data []byte, t duckdb.Type) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+	s := string(data)
+	switch typeOID(t) {
+	case oidBool:
+		switch strings.ToLower(s) {
+		case "t", "true", "y", "yes", "on", "1":
+			return true, nil
+		case "f", "false", "n", "no", "off", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", s)
+	case oidInt2, oidInt4, oidInt8:
+		return strconv.ParseInt(s, 10, 64)
+	case oidFloat4, oidFloat8:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+// pgError is an error reported to the client with a SQLSTATE code.
+type pgError struct {
+	code string
+	msg  string
+}
+
+func (e *pgError) Error() string {
+	return e.msg
+}
+
+// SQLSTATE codes, from PostgreSQL's errcodes.
+const (
+	codeProtocolViolation   = "08P01"
+	codeFeatureNotSupported = "0A000"
+	codeReadOnly            = "25006"
+	codeSyntaxError         = "42601"
+	codeUndefinedTable      = "42P01"
+	codeUndefinedColumn     = "42703"
+	codeInsufficientPriv    = "42501"
+	codeInvalidParameter    = "22023"
+	codeDataException       = "22000"
+	codeQueryCanceled       = "57014"
+	codeInternalError       = "XX000"
+)
+
+// errorCode picks the SQLSTATE code for an error.
+func errorCode(err error) string {
+	var pgErr *pgError
+	var duckErr *duckdb.Error
+	switch {
+	case errors.As(err, &pgErr):
+		return pgErr.code
+	case errors.Is(err, schema.ErrNotReadOnly):
+		return codeReadOnly
+	case errors.As(err, &duckErr):
+		switch duckErr.Type {
+		case duckdb.ErrorTypeParser:
+			return codeSyntaxError
+		case duckdb.ErrorTypeCatalog:
+			return codeUndefinedTable
+		case duckdb.ErrorTypeBinder:
+			return codeUndefinedColumn
+		case duckdb.ErrorTypePermission:
+			return codeInsufficientPriv
+		case duckdb.ErrorTypeInterrupt:
+			return codeQueryCanceled
+		case duckdb.ErrorTypeConversion, duckdb.ErrorTypeOutOfRange, duckdb.ErrorTypeDivideByZero, duckdb.ErrorTypeInvalidInput:
+			return codeDataException
+		}
+	}
+	return codeInternalError
+}
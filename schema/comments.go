@@ -0,0 +1,285 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// tableComments documents the tables and views. They are registered with
+// COMMENT ON, so they appear in information_schema alongside the columns.
+var tableComments = map[string]string{
+	"files":               "Proto files",
+	"messages":            "Message definitions, including nested messages and map entries",
+	"fields":              "Message fields",
+	"enums":               "Enum definitions",
+	"enum_values":         "Enum value definitions",
+	"services":            "Service definitions",
+	"methods":             "RPC method definitions",
+	"extensions":          "Extension definitions",
+	"oneofs":              "Oneof definitions, including synthetic oneofs of proto3 optional fields",
+	"oneof_fields":        "Fields belonging to each oneof",
+	"dependencies":        "File imports",
+	"http_rules":          "google.api.http bindings per method",
+	"http_path_params":    "Path template variables of HTTP bindings, linked to fields",
+	"field_constraints":   "protovalidate and protoc-gen-validate field rules",
+	"message_constraints": "Message-level validation rules (CEL expressions, disabled validation)",
+	"enum_ranges":         "Per-enum numbering summary: range, aliases and gaps",
+	"all_fields":          "Message fields together with the extensions of each message",
+}
+
+// Descriptions shared by columns of several tables.
+const (
+	optionsComment    = "Options set on the element, as JSON keyed by option name (extensions by full name)"
+	featuresComment   = "Resolved edition features, including inherited values, as JSON"
+	deprecatedComment = "Whether the deprecated option is set"
+	fileComment       = "Path of the defining file (files.name)"
+	parentComment     = "Full name of the enclosing message, for nested definitions"
+)
+
+// columnComments documents each column, by table and column name.
+var columnComments = map[string]map[string]string{
+	"files": {
+		"name":     "Path of the file, relative to its import root",
+		"package":  "Proto package",
+		"syntax":   "proto2, proto3 or editions",
+		"edition":  "Effective edition: proto2, proto3 or the edition year (e.g. 2023)",
+		"options":  optionsComment,
+		"features": featuresComment,
+	},
+	"messages": {
+		"full_name":      "Fully qualified name",
+		"name":           "Short name",
+		"file":           fileComment,
+		"parent_message": parentComment,
+		"is_map_entry":   "Whether this is the synthesized entry message of a map field",
+		"is_deprecated":  deprecatedComment,
+		"options":        optionsComment,
+		"features":       featuresComment,
+	},
+	"fields": {
+		"id":                 "Full name of the field (message full name and field name)",
+		"name":               "Field name",
+		"number":             "Field number",
+		"message":            "Full name of the containing message",
+		"type":               "Scalar type, or message, enum or group",
+		"type_name":          "Full name of the message or enum type",
+		"label":              "optional, required or repeated",
+		"is_repeated":        "Whether the field is repeated (including maps)",
+		"is_optional":        "Whether the field is declared optional",
+		"is_map":             "Whether the field is a map",
+		"map_key_type":       "Key type of a map field",
+		"map_value_type":     "Value type of a map field (full name for messages and enums)",
+		"default_value":      "Explicit default value (proto2)",
+		"json_name":          "JSON name",
+		"has_presence":       "Whether the field tracks presence (has_ methods)",
+		"is_packed":          "Whether a repeated scalar field uses packed encoding",
+		"is_deprecated":      deprecatedComment,
+		"is_group":           "Whether the field is a proto2 group or uses delimited encoding",
+		"oneof_name":         "Name of the containing oneof",
+		"is_synthetic_oneof": "Whether the containing oneof is synthesized for a proto3 optional field",
+		"jstype":             "jstype option (JS_NORMAL, JS_STRING, JS_NUMBER)",
+		"ctype":              "ctype option (STRING, CORD, STRING_PIECE)",
+		"is_lazy":            "Whether the lazy option is set",
+		"options":            optionsComment,
+		"features":           featuresComment,
+	},
+	"enums": {
+		"full_name":       "Fully qualified name",
+		"name":            "Short name",
+		"file":            fileComment,
+		"parent_message":  parentComment,
+		"is_closed":       "Whether the enum is closed (proto2 semantics: unknown values are rejected)",
+		"allow_alias":     "Whether the allow_alias option is set",
+		"reserved_names":  "Reserved value names",
+		"reserved_ranges": "Reserved number ranges, as JSON objects with inclusive start and end",
+		"is_deprecated":   deprecatedComment,
+		"options":         optionsComment,
+		"features":        featuresComment,
+	},
+	"enum_values": {
+		"id":            "Full name of the value (enum full name and value name)",
+		"name":          "Value name",
+		"number":        "Value number",
+		"enum":          "Full name of the enum",
+		"is_alias_of":   "Id of the first value with the same number, when this value is an alias",
+		"is_deprecated": deprecatedComment,
+		"options":       optionsComment,
+	},
+	"services": {
+		"full_name":     "Fully qualified name",
+		"name":          "Short name",
+		"file":          fileComment,
+		"is_deprecated": deprecatedComment,
+		"options":       optionsComment,
+	},
+	"methods": {
+		"full_name":        "Fully qualified name",
+		"name":             "Short name",
+		"service":          "Full name of the service",
+		"input_type":       "Full name of the request message",
+		"output_type":      "Full name of the response message",
+		"client_streaming": "Whether the request is a stream",
+		"server_streaming": "Whether the response is a stream",
+		"is_deprecated":    deprecatedComment,
+		"options":          optionsComment,
+	},
+	"extensions": {
+		"full_name":     "Fully qualified name",
+		"name":          "Short name",
+		"number":        "Field number",
+		"file":          fileComment,
+		"extendee":      "Full name of the extended message",
+		"type":          "Scalar type, or message, enum or group",
+		"type_name":     "Full name of the message or enum type",
+		"label":         "optional, required or repeated",
+		"has_presence":  "Whether the extension tracks presence",
+		"is_packed":     "Whether a repeated scalar extension uses packed encoding",
+		"is_deprecated": deprecatedComment,
+		"options":       optionsComment,
+	},
+	"oneofs": {
+		"id":       "Full name of the oneof (message full name and oneof name)",
+		"name":     "Oneof name",
+		"message":  "Full name of the containing message",
+		"options":  optionsComment,
+		"features": featuresComment,
+	},
+	"oneof_fields": {
+		"oneof_id": "Id of the oneof (oneofs.id)",
+		"field_id": "Id of the field (fields.id)",
+	},
+	"dependencies": {
+		"file":       "Path of the importing file",
+		"dependency": "Path of the imported file",
+		"is_public":  "Whether this is an import public",
+		"is_weak":    "Whether this is an import weak",
+	},
+	"http_rules": {
+		"method":        "Full name of the method",
+		"verb":          "HTTP verb (GET, POST, ...) or the custom verb",
+		"path_template": "URL path template",
+		"body":          "Request field mapped to the HTTP body, or * for the whole request",
+		"response_body": "Response field mapped to the HTTP body",
+		"binding_index": "0 for the primary binding, 1 and up for additional_bindings",
+	},
+	"http_path_params": {
+		"method":        "Full name of the method",
+		"binding_index": "Binding the variable belongs to (http_rules.binding_index)",
+		"field_path":    "Request field path bound by the variable (e.g. book.name)",
+		"pattern":       "Segment pattern the variable matches (* when unspecified)",
+		"field_id":      "Id of the bound field (fields.id), when the path resolves",
+	},
+	"field_constraints": {
+		"field_id":       "Id of the constrained field (fields.id, or extensions.full_name)",
+		"rule_family":    "Rule group: the type rules (string, int32, repeated, ...), cel for CEL rules, or field",
+		"rule":           "Rule name, e.g. min_len, or the id of a CEL rule",
+		"value":          "Rule argument (JSON for non-strings), or the CEL rule's message",
+		"cel_expression": "CEL expression of custom rules",
+		"source":         "protovalidate or protoc-gen-validate",
+	},
+	"message_constraints": {
+		"message":        "Full name of the constrained message",
+		"rule_family":    "cel for CEL rules, or message for flags such as disabled",
+		"rule":           "Id of a CEL rule, or the flag name",
+		"value":          "Rule argument (JSON for non-strings), or the CEL rule's message",
+		"cel_expression": "CEL expression of custom rules",
+		"source":         "protovalidate or protoc-gen-validate",
+	},
+	"all_fields": {
+		"id":                 "Full name of the field (fields.id), or of the extension",
+		"name":               "Field name",
+		"number":             "Field number",
+		"message":            "Full name of the containing message, or the extendee",
+		"type":               "Scalar type, or message, enum or group",
+		"type_name":          "Full name of the message or enum type",
+		"label":              "optional, required or repeated",
+		"has_presence":       "Whether the field tracks presence (has_ methods)",
+		"is_packed":          "Whether a repeated scalar field uses packed encoding",
+		"is_deprecated":      deprecatedComment,
+		"is_extension_field": "Whether the field is an extension",
+		"options":            optionsComment,
+	},
+	"enum_ranges": {
+		"enum":             "Full name of the enum",
+		"min_number":       "Lowest value number",
+		"max_number":       "Highest value number",
+		"value_count":      "Number of values, including aliases",
+		"distinct_numbers": "Number of distinct value numbers",
+		"alias_count":      "Number of values sharing a number with an earlier value",
+		"gap_count":        "Number of unused ranges between min_number and max_number",
+		"gaps":             "Unused numbers and ranges (e.g. 3-4) between min_number and max_number",
+	},
+}
+
+// commentStatements returns the COMMENT ON statements registering the table
+// and column descriptions.
+func commentStatements() []string {
+	var stmts []string
+	for table, comment := range tableComments {
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON TABLE %s IS %s", table, quote(comment)))
+	}
+	for table, columns := range columnComments {
+		for column, comment := range columns {
+			stmts = append(stmts, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", table, column, quote(comment)))
+		}
+	}
+	return stmts
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Table is a table or view in the catalog.
+type Table struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"` // "table" or "view"
+	Comment string   `json:"comment"`
+	Columns []Column `json:"columns,omitempty"`
+}
+
+// Column is a column of a table or view in the catalog.
+type Column struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Comment string `json:"comment"`
+}
+
+// Catalog reads the tables and views, with their columns and descriptions,
+// from information_schema. Tables come before views, each sorted by name.
+func Catalog(ctx context.Context, db *sql.DB) ([]Table, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.table_name,
+			CASE t.table_type WHEN 'VIEW' THEN 'view' ELSE 'table' END,
+			COALESCE(t.table_comment, ''),
+			c.column_name,
+			c.data_type,
+			COALESCE(c.column_comment, '')
+		FROM information_schema.tables t
+		JOIN information_schema.columns c
+			ON c.table_catalog = t.table_catalog
+			AND c.table_schema = t.table_schema
+			AND c.table_name = t.table_name
+		ORDER BY t.table_type = 'VIEW', t.table_name, c.ordinal_position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var t Table
+		var c Column
+		if err := rows.Scan(&t.Name, &t.Type, &t.Comment, &c.Name, &c.Type, &c.Comment); err != nil {
+			return nil, fmt.Errorf("failed to read catalog: %w", err)
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Name != t.Name {
+			tables = append(tables, t)
+		}
+		last := &tables[len(tables)-1]
+		last.Columns = append(last.Columns, c)
+	}
+	return tables, rows.Err()
+}
@@ -0,0 +1,336 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Definition renders a proto element as source, with its leading
+// comments and options. Type names are fully qualified.
+func Definition(d protoreflect.Descriptor) string {
+	p := &protoPrinter{}
+	switch d := d.(type) {
+	case protoreflect.MessageDescriptor:
+		p.message(d)
+	case protoreflect.EnumDescriptor:
+		p.enum(d)
+	case protoreflect.ServiceDescriptor:
+		p.service(d)
+	case protoreflect.MethodDescriptor:
+		p.method(d)
+	case protoreflect.EnumValueDescriptor:
+		p.enumValue(d)
+	case protoreflect.FieldDescriptor:
+		if d.IsExtension() {
+			p.extension(d)
+		} else {
+			p.field(d)
+		}
+	case protoreflect.OneofDescriptor:
+		p.oneof(d)
+	default:
+		p.line("// %s", d.FullName())
+	}
+	return strings.TrimSuffix(p.b.String(), "\n")
+}
+
+type protoPrinter struct {
+	b      strings.Builder
+	indent int
+}
+
+func (p *protoPrinter) line(format string, args ...any) {
+	p.b.WriteString(strings.Repeat("  ", p.indent))
+	fmt.Fprintf(&p.b, format, args...)
+	p.b.WriteString("\n")
+}
+
+func (p *protoPrinter) comments(d protoreflect.Descriptor) {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	comment := strings.TrimSuffix(loc.LeadingComments, "\n")
+	if comment == "" {
+		return
+	}
+	for _, l := range strings.Split(comment, "\n") {
+		p.line("//%s", l)
+	}
+}
+
+// options prints the options set on an element as option statements.
+func (p *protoPrinter) options(opts protoreflect.ProtoMessage) {
+	for _, opt := range formatOptions(opts) {
+		p.line("option %s;", opt)
+	}
+}
+
+func (p *protoPrinter) message(m protoreflect.MessageDescriptor) {
+	p.comments(m)
+	p.line("message %s {", m.Name())
+	p.indent++
+	p.options(m.Options())
+
+	printed := make(map[protoreflect.OneofDescriptor]bool)
+	fields := m.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if o := f.ContainingOneof(); o != nil && !o.IsSynthetic() {
+			if !printed[o] {
+				printed[o] = true
+				p.oneof(o)
+			}
+			continue
+		}
+		p.field(f)
+	}
+
+	for i := 0; i < m.Messages().Len(); i++ {
+		if nested := m.Messages().Get(i); !nested.IsMapEntry() {
+			p.message(nested)
+		}
+	}
+	for i := 0; i < m.Enums().Len(); i++ {
+		p.enum(m.Enums().Get(i))
+	}
+	for i := 0; i < m.Extensions().Len(); i++ {
+		p.extension(m.Extensions().Get(i))
+	}
+	var ranges [][2]int
+	for i := 0; i < m.ReservedRanges().Len(); i++ {
+		r := m.ReservedRanges().Get(i)
+		ranges = append(ranges, [2]int{int(r[0]), int(r[1])})
+	}
+	p.reserved(ranges, m.ReservedNames(), true)
+	p.indent--
+	p.line("}")
+}
+
+func (p *protoPrinter) oneof(o protoreflect.OneofDescriptor) {
+	p.comments(o)
+	p.line("oneof %s {", o.Name())
+	p.indent++
+	p.options(o.Options())
+	for i := 0; i < o.Fields().Len(); i++ {
+		p.field(o.Fields().Get(i))
+	}
+	p.indent--
+	p.line("}")
+}
+
+func (p *protoPrinter) field(f protoreflect.FieldDescriptor) {
+	p.comments(f)
+	p.line("%s%s %s = %d%s;", fieldLabel(f), fieldType(f), f.Name(), f.Number(), inlineOptions(f.Options()))
+}
+
+func (p *protoPrinter) extension(f protoreflect.FieldDescriptor) {
+	p.line("extend %s {", f.ContainingMessage().FullName())
+	p.indent++
+	p.field(f)
+	p.indent--
+	p.line("}")
+}
+
+func (p *protoPrinter) enum(e protoreflect.EnumDescriptor) {
+	p.comments(e)
+	p.line("enum %s {", e.Name())
+	p.indent++
+	p.options(e.Options())
+	for i := 0; i < e.Values().Len(); i++ {
+		p.enumValue(e.Values().Get(i))
+	}
+	var ranges [][2]int
+	for i := 0; i < e.ReservedRanges().Len(); i++ {
+		r := e.ReservedRanges().Get(i)
+		ranges = append(ranges, [2]int{int(r[0]), int(r[1])})
+	}
+	p.reserved(ranges, e.ReservedNames(), false)
+	p.indent--
+	p.line("}")
+}
+
+func (p *protoPrinter) enumValue(v protoreflect.EnumValueDescriptor) {
+	p.comments(v)
+	p.line("%s = %d%s;", v.Name(), v.Number(), inlineOptions(v.Options()))
+}
+
+func (p *protoPrinter) service(s protoreflect.ServiceDescriptor) {
+	p.comments(s)
+	p.line("service %s {", s.Name())
+	p.indent++
+	p.options(s.Options())
+	for i := 0; i < s.Methods().Len(); i++ {
+		p.method(s.Methods().Get(i))
+	}
+	p.indent--
+	p.line("}")
+}
+
+func (p *protoPrinter) method(m protoreflect.MethodDescriptor) {
+	p.comments(m)
+	stream := func(streaming bool) string {
+		if streaming {
+			return "stream "
+		}
+		return ""
+	}
+	signature := fmt.Sprintf("rpc %s(%s%s) returns (%s%s)", m.Name(),
+		stream(m.IsStreamingClient()), m.Input().FullName(),
+		stream(m.IsStreamingServer()), m.Output().FullName())
+
+	opts := formatOptions(m.Options())
+	if len(opts) == 0 {
+		p.line("%s;", signature)
+		return
+	}
+	p.line("%s {", signature)
+	p.indent++
+	for _, opt := range opts {
+		p.line("option %s;", opt)
+	}
+	p.indent--
+	p.line("}")
+}
+
+// reserved prints reserved ranges and names. Message ranges are exclusive
+// of their end; enum ranges are inclusive.
+func (p *protoPrinter) reserved(ranges [][2]int, names protoreflect.Names, exclusiveEnd bool) {
+	var parts []string
+	for _, r := range ranges {
+		start, end := r[0], r[1]
+		if exclusiveEnd {
+			end--
+		}
+		if start == end {
+			parts = append(parts, strconv.Itoa(start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d to %d", start, end))
+		}
+	}
+	if len(parts) > 0 {
+		p.line("reserved %s;", strings.Join(parts, ", "))
+	}
+
+	parts = nil
+	for i := 0; i < names.Len(); i++ {
+		parts = append(parts, strconv.Quote(string(names.Get(i))))
+	}
+	if len(parts) > 0 {
+		p.line("reserved %s;", strings.Join(parts, ", "))
+	}
+}
+
+// fieldLabel returns the label keyword (with a trailing space) shown before
+// a field's type.
+func fieldLabel(f protoreflect.FieldDescriptor) string {
+	switch {
+	case f.IsMap():
+		return ""
+	case f.Cardinality() == protoreflect.Repeated:
+		return "repeated "
+	case f.Cardinality() == protoreflect.Required && f.ParentFile().Syntax() == protoreflect.Proto2:
+		return "required "
+	case f.ContainingOneof() != nil && !f.ContainingOneof().IsSynthetic():
+		return ""
+	case f.ParentFile().Syntax() == protoreflect.Proto2 || f.HasOptionalKeyword():
+		return "optional "
+	}
+	return ""
+}
+
+func fieldType(f protoreflect.FieldDescriptor) string {
+	if f.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", fieldType(f.MapKey()), fieldType(f.MapValue()))
+	}
+	switch f.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(f.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(f.Enum().FullName())
+	}
+	return f.Kind().String()
+}
+
+// inlineOptions renders options in the bracketed form used by fields and
+// enum values, with a leading space.
+func inlineOptions(opts protoreflect.ProtoMessage) string {
+	formatted := formatOptions(opts)
+	if len(formatted) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(formatted, ", ") + "]"
+}
+
+// formatOptions renders each option set on an options message as
+// "name = value", with extension names in parentheses, sorted by name.
+func formatOptions(opts protoreflect.ProtoMessage) []string {
+	if opts == nil {
+		return nil
+	}
+	msg := opts.ProtoReflect()
+	if !msg.IsValid() {
+		return nil
+	}
+
+	var formatted []string
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := string(fd.Name())
+		if fd.IsExtension() {
+			name = "(" + string(fd.FullName()) + ")"
+		}
+		if fd.IsList() {
+			for i := 0; i < v.List().Len(); i++ {
+				formatted = append(formatted, name+" = "+formatValue(fd, v.List().Get(i)))
+			}
+			return true
+		}
+		formatted = append(formatted, name+" = "+formatValue(fd, v))
+		return true
+	})
+	sort.Strings(formatted)
+	return formatted
+}
+
+// formatValue renders an option value in proto text syntax, on one line.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return strconv.Quote(v.String())
+	case protoreflect.BytesKind:
+		return strconv.Quote(string(v.Bytes()))
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		var parts []string
+		v.Message().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			name := string(fd.Name())
+			if fd.IsExtension() {
+				name = "[" + string(fd.FullName()) + "]"
+			}
+			switch {
+			case fd.IsList():
+				for i := 0; i < v.List().Len(); i++ {
+					parts = append(parts, name+": "+formatValue(fd, v.List().Get(i)))
+				}
+			case fd.IsMap():
+				v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+					parts = append(parts, fmt.Sprintf("%s: { key: %v value: %s }", name, k.Interface(), formatValue(fd.MapValue(), mv)))
+					return true
+				})
+			default:
+				parts = append(parts, name+": "+formatValue(fd, v))
+			}
+			return true
+		})
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(parts, " ") + " }"
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// descriptorColumns are the columns QueryDescriptors reads names from, in
+// order of preference.
+var descriptorColumns = []string{"full_name", "id"}
+
+// register adds a file's elements to the descriptor registry, keyed by the
+// names used in the tables. Names already registered by another file are
+// kept.
+func (d *DB) register(f protoreflect.FileDescriptor) {
+	add := func(name string, desc protoreflect.Descriptor) {
+		if _, ok := d.descriptors[name]; !ok {
+			d.descriptors[name] = desc
+		}
+	}
+	var addEnum func(protoreflect.EnumDescriptor)
+	addEnum = func(enum protoreflect.EnumDescriptor) {
+		add(string(enum.FullName()), enum)
+		// Enum values are scoped alongside their enum, but enum_values ids
+		// are the enum's full name and the value name
+		for i := 0; i < enum.Values().Len(); i++ {
+			val := enum.Values().Get(i)
+			add(fmt.Sprintf("%s.%s", enum.FullName(), val.Name()), val)
+			add(string(val.FullName()), val)
+		}
+	}
+	addFields := func(fields protoreflect.ExtensionDescriptors) {
+		for i := 0; i < fields.Len(); i++ {
+			add(string(fields.Get(i).FullName()), fields.Get(i))
+		}
+	}
+	var addMessage func(protoreflect.MessageDescriptor)
+	addMessage = func(msg protoreflect.MessageDescriptor) {
+		add(string(msg.FullName()), msg)
+		for i := 0; i < msg.Fields().Len(); i++ {
+			add(string(msg.Fields().Get(i).FullName()), msg.Fields().Get(i))
+		}
+		for i := 0; i < msg.Oneofs().Len(); i++ {
+			add(string(msg.Oneofs().Get(i).FullName()), msg.Oneofs().Get(i))
+		}
+		for i := 0; i < msg.Messages().Len(); i++ {
+			addMessage(msg.Messages().Get(i))
+		}
+		for i := 0; i < msg.Enums().Len(); i++ {
+			addEnum(msg.Enums().Get(i))
+		}
+		addFields(msg.Extensions())
+	}
+
+	for i := 0; i < f.Messages().Len(); i++ {
+		addMessage(f.Messages().Get(i))
+	}
+	for i := 0; i < f.Enums().Len(); i++ {
+		addEnum(f.Enums().Get(i))
+	}
+	for i := 0; i < f.Services().Len(); i++ {
+		svc := f.Services().Get(i)
+		add(string(svc.FullName()), svc)
+		for j := 0; j < svc.Methods().Len(); j++ {
+			add(string(svc.Methods().Get(j).FullName()), svc.Methods().Get(j))
+		}
+	}
+	addFields(f.Extensions())
+}
+
+// Descriptor returns the loaded element (message, field, oneof, enum, enum
+// value, service, method or extension) with the given full name or table
+// id, or nil. A leading dot, as in type_name references, is ignored.
+func (d *DB) Descriptor(fullName string) protoreflect.Descriptor {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.descriptors[strings.TrimPrefix(fullName, ".")]
+}
+
+// QueryDescriptors runs a query and returns the descriptor named by each
+// row's full_name column, or its id column when there is none. It fails if
+// neither column is selected or a row names no loaded element.
+func (d *DB) QueryDescriptors(ctx context.Context, query string, args ...any) ([]protoreflect.Descriptor, error) {
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	col := -1
+	for _, name := range descriptorColumns {
+		if col = slices.Index(cols, name); col >= 0 {
+			break
+		}
+	}
+	if col < 0 {
+		return nil, fmt.Errorf("query selects neither %s", strings.Join(descriptorColumns, " nor "))
+	}
+
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	descs := []protoreflect.Descriptor{}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		name, ok := values[col].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s is %v, not a name", cols[col], values[col])
+		}
+		desc := d.Descriptor(name)
+		if desc == nil {
+			return nil, fmt.Errorf("no loaded element named %s", name)
+		}
+		descs = append(descs, desc)
+	}
+	return descs, rows.Err()
+}
@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// httpRuleExtension is the method option carrying REST mappings.
+const httpRuleExtension protoreflect.FullName = "google.api.http"
+
+// httpVerbs lists the google.api.HttpRule pattern fields that map directly
+// to an HTTP verb.
+var httpVerbs = []protoreflect.Name{"get", "put", "post", "delete", "patch"}
+
+// httpBinding is a single verb/path pair from a google.api.HttpRule.
+type httpBinding struct {
+	verb         string
+	pathTemplate string
+	body         string
+	responseBody string
+}
+
+// httpPathParam is a variable captured by a path template, e.g. the
+// "{name=shelves/*}" in "/v1/{name=shelves/*}/books".
+type httpPathParam struct {
+	fieldPath string
+	pattern   string
+}
+
+// loadHTTPRules records the google.api.http annotation of a method, if any,
+// with one row per binding (the primary rule is binding 0, followed by its
+// additional_bindings) and one row per path variable.
+func loadHTTPRules(bl *bulkLoader, method protoreflect.MethodDescriptor, methodFullName string) error {
+	fd, v, ok := findOption(method.Options(), httpRuleExtension)
+	if !ok || fd.Message() == nil {
+		return nil
+	}
+	rule := v.Message()
+
+	bindings := []httpBinding{parseHTTPBinding(rule)}
+	if fd := rule.Descriptor().Fields().ByName("additional_bindings"); fd != nil && fd.IsList() {
+		list := rule.Get(fd).List()
+		for i := 0; i < list.Len(); i++ {
+			bindings = append(bindings, parseHTTPBinding(list.Get(i).Message()))
+		}
+	}
+
+	for i, b := range bindings {
+		if b.pathTemplate == "" {
+			continue
+		}
+
+		if err := bl.httpRules.AppendRow(
+			methodFullName,
+			b.verb,
+			b.pathTemplate,
+			nullIfEmpty(b.body),
+			nullIfEmpty(b.responseBody),
+			int32(i),
+		); err != nil {
+			return fmt.Errorf("failed to append http rule for %s: %w", methodFullName, err)
+		}
+
+		for _, p := range parsePathTemplate(b.pathTemplate) {
+			var fieldID any
+			if id, ok := resolveFieldPath(method.Input(), p.fieldPath); ok {
+				fieldID = id
+			}
+			if err := bl.httpPathParams.AppendRow(methodFullName, int32(i), p.fieldPath, p.pattern, fieldID); err != nil {
+				return fmt.Errorf("failed to append http path param for %s: %w", methodFullName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseHTTPBinding reads the pattern, body and response_body of a single
+// HttpRule message by field name, so it works with dynamic messages.
+func parseHTTPBinding(rule protoreflect.Message) httpBinding {
+	var b httpBinding
+	fields := rule.Descriptor().Fields()
+
+	for _, verb := range httpVerbs {
+		if fd := fields.ByName(verb); fd != nil && rule.Has(fd) {
+			b.verb = strings.ToUpper(string(verb))
+			b.pathTemplate = rule.Get(fd).String()
+		}
+	}
+
+	if fd := fields.ByName("custom"); fd != nil && rule.Has(fd) {
+		custom := rule.Get(fd).Message()
+		customFields := custom.Descriptor().Fields()
+		if kind := customFields.ByName("kind"); kind != nil {
+			b.verb = custom.Get(kind).String()
+		}
+		if path := customFields.ByName("path"); path != nil {
+			b.pathTemplate = custom.Get(path).String()
+		}
+	}
+
+	if fd := fields.ByName("body"); fd != nil {
+		b.body = rule.Get(fd).String()
+	}
+	if fd := fields.ByName("response_body"); fd != nil {
+		b.responseBody = rule.Get(fd).String()
+	}
+
+	return b
+}
+
+// parsePathTemplate extracts the variables of a path template. A bare
+// "{name}" matches a single segment, so its pattern is reported as "*".
+func parsePathTemplate(template string) []httpPathParam {
+	var params []httpPathParam
+	for {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			return params
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			return params
+		}
+
+		variable := template[start+1 : start+end]
+		fieldPath, pattern, ok := strings.Cut(variable, "=")
+		if !ok {
+			pattern = "*"
+		}
+		params = append(params, httpPathParam{fieldPath: strings.TrimSpace(fieldPath), pattern: pattern})
+
+		template = template[start+end+1:]
+	}
+}
+
+// resolveFieldPath walks a dotted field path (e.g. "book.name") from a
+// message and returns the fields table id of the final field.
+func resolveFieldPath(msg protoreflect.MessageDescriptor, fieldPath string) (string, bool) {
+	parts := strings.Split(fieldPath, ".")
+	for i, part := range parts {
+		if msg == nil {
+			return "", false
+		}
+		fd := msg.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			return "", false
+		}
+		if i == len(parts)-1 {
+			return fmt.Sprintf("%s.%s", msg.FullName(), fd.Name()), true
+		}
+		msg = fd.Message()
+	}
+	return "", false
+}
+
+// nullIfEmpty maps "" to NULL for optional string columns.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotReadOnly is returned by CheckReadOnly for statements that could
+// change the database.
+var ErrNotReadOnly = errors.New("read-only mode: only SELECT statements are allowed")
+
+// SetReadOnly locks the database down for untrusted queries: DuckDB can no
+// longer read or write files, install or load extensions or attach other
+// databases, and its configuration is locked so that queries cannot undo
+// this. Protos can still be loaded. DuckDB still runs DDL and DML against
+// the tables, so queries must also pass CheckQuery.
+func (d *DB) SetReadOnly() error {
+	for _, stmt := range []string{
+		"SET enable_external_access = false",
+		"SET lock_configuration = true",
+	} {
+		if _, err := d.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to enable read-only mode: %w", err)
+		}
+	}
+	d.mu.Lock()
+	d.readOnly = true
+	d.mu.Unlock()
+	return nil
+}
+
+// ReadOnly reports whether SetReadOnly was called.
+func (d *DB) ReadOnly() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.readOnly
+}
+
+// CheckQuery reports whether a query may run: any query normally, and only
+// SELECT statements in read-only mode, as checked by CheckReadOnly.
+func (d *DB) CheckQuery(ctx context.Context, query string) error {
+	if !d.ReadOnly() {
+		return nil
+	}
+	return CheckReadOnly(ctx, d.DB, query)
+}
+
+// CheckReadOnly returns ErrNotReadOnly unless every statement in query is a
+// SELECT, including its shorthands such as FROM, DESCRIBE, SHOW and
+// SUMMARIZE. The statements are parsed by DuckDB, not run.
+func CheckReadOnly(ctx context.Context, db *sql.DB, query string) error {
+	// json_serialize_sql only serializes SELECT statements, reporting an
+	// error for any other kind, so it classifies a script without running
+	// any of it
+	var serialized string
+	if err := db.QueryRowContext(ctx, "SELECT json_serialize_sql(?::VARCHAR)::VARCHAR", query).Scan(&serialized); err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	var result struct {
+		Error        bool   `json:"error"`
+		ErrorType    string `json:"error_type"`
+		ErrorMessage string `json:"error_message"`
+	}
+	if err := json.Unmarshal([]byte(serialized), &result); err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+	switch {
+	case !result.Error:
+		return nil
+	case result.ErrorType == "not implemented":
+		return ErrNotReadOnly
+	}
+	return fmt.Errorf("failed to parse query: %s", result.ErrorMessage)
+}
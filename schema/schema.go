@@ -5,21 +5,51 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/protoutil"
 	"github.com/duckdb/duckdb-go/v2"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // DB wraps the DuckDB connection with proto-specific operations.
 type DB struct {
 	*sql.DB
-	conn driver.Conn
+	// sqlConn holds the connection conn is taken from, for bulk loading
+	sqlConn *sql.Conn
+	conn    driver.Conn
+	// mu guards the loaded files (files in load order, loaded indexing
+	// them by path and descriptors their elements by full name or id) and
+	// readOnly, set by SetReadOnly
+	mu          sync.RWMutex
+	files       []linker.File
+	loaded      map[string]bool
+	descriptors map[string]protoreflect.Descriptor
+	readOnly    bool
 }
 
+// tables are the tables filled by LoadFiles, emptied by Reset.
+var tables = []string{
+	"files", "messages", "fields", "enums", "enum_values", "services", "methods",
+	"extensions", "oneofs", "oneof_fields", "dependencies", "http_rules",
+	"http_path_params", "field_constraints", "message_constraints",
+}
+
+// New creates the schema in an in-memory database.
 func New() (*DB, error) {
-	db, err := sql.Open("duckdb", "")
+	return Open("")
+}
+
+// Open creates the schema in a DuckDB database file, or in memory when path
+// is empty. Tables and views left in the file by a previous Open are dropped
+// first, so the file only holds what is loaded from then on.
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("duckdb", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open duckdb: %w", err)
 	}
@@ -40,25 +70,61 @@ func New() (*DB, error) {
 		return nil, fmt.Errorf("failed to get driver connection: %w", err)
 	}
 
-	d := &DB{DB: db, conn: driverConn}
+	d := &DB{
+		DB:          db,
+		sqlConn:     conn,
+		conn:        driverConn,
+		loaded:      make(map[string]bool),
+		descriptors: make(map[string]protoreflect.Descriptor),
+	}
+	if path != "" {
+		if err := d.dropSchema(); err != nil {
+			d.Close()
+			return nil, err
+		}
+	}
 	if err := d.createSchema(); err != nil {
-		db.Close()
+		d.Close()
 		return nil, err
 	}
 
 	return d, nil
 }
 
+// Close releases the bulk loading connection and closes the database.
+func (d *DB) Close() error {
+	d.sqlConn.Close()
+	return d.DB.Close()
+}
+
+// dropSchema removes the tables and views created by createSchema.
+func (d *DB) dropSchema() error {
+	for _, view := range []string{"enum_ranges", "all_fields"} {
+		if _, err := d.Exec("DROP VIEW IF EXISTS " + view); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", view, err)
+		}
+	}
+	for _, table := range tables {
+		if _, err := d.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
 func (d *DB) createSchema() error {
 	// Create tables without foreign key constraints for faster loading
 	// DuckDB doesn't enforce FK constraints anyway, they're just metadata
 	// Note: options stored as JSON - query directly with -> or json_extract_string
+	// Note: features holds the resolved edition features, including inherited values
 	schemas := []string{
 		`CREATE TABLE files (
 			name VARCHAR PRIMARY KEY,
 			package VARCHAR,
 			syntax VARCHAR,
-			options JSON
+			edition VARCHAR,
+			options JSON,
+			features JSON
 		)`,
 		`CREATE TABLE messages (
 			full_name VARCHAR PRIMARY KEY,
@@ -66,7 +132,9 @@ func (d *DB) createSchema() error {
 			file VARCHAR NOT NULL,
 			parent_message VARCHAR,
 			is_map_entry BOOLEAN DEFAULT FALSE,
-			options JSON
+			is_deprecated BOOLEAN DEFAULT FALSE,
+			options JSON,
+			features JSON
 		)`,
 		`CREATE TABLE fields (
 			id VARCHAR PRIMARY KEY,
@@ -83,26 +151,45 @@ func (d *DB) createSchema() error {
 			map_value_type VARCHAR,
 			default_value VARCHAR,
 			json_name VARCHAR,
-			options JSON
+			has_presence BOOLEAN DEFAULT FALSE,
+			is_packed BOOLEAN DEFAULT FALSE,
+			is_deprecated BOOLEAN DEFAULT FALSE,
+			is_group BOOLEAN DEFAULT FALSE,
+			oneof_name VARCHAR,
+			is_synthetic_oneof BOOLEAN DEFAULT FALSE,
+			jstype VARCHAR,
+			ctype VARCHAR,
+			is_lazy BOOLEAN DEFAULT FALSE,
+			options JSON,
+			features JSON
 		)`,
 		`CREATE TABLE enums (
 			full_name VARCHAR PRIMARY KEY,
 			name VARCHAR NOT NULL,
 			file VARCHAR NOT NULL,
 			parent_message VARCHAR,
-			options JSON
+			is_closed BOOLEAN DEFAULT FALSE,
+			allow_alias BOOLEAN DEFAULT FALSE,
+			reserved_names VARCHAR[],
+			reserved_ranges JSON,
+			is_deprecated BOOLEAN DEFAULT FALSE,
+			options JSON,
+			features JSON
 		)`,
 		`CREATE TABLE enum_values (
 			id VARCHAR PRIMARY KEY,
 			name VARCHAR NOT NULL,
 			number INTEGER NOT NULL,
 			enum VARCHAR NOT NULL,
+			is_alias_of VARCHAR,
+			is_deprecated BOOLEAN DEFAULT FALSE,
 			options JSON
 		)`,
 		`CREATE TABLE services (
 			full_name VARCHAR PRIMARY KEY,
 			name VARCHAR NOT NULL,
 			file VARCHAR NOT NULL,
+			is_deprecated BOOLEAN DEFAULT FALSE,
 			options JSON
 		)`,
 		`CREATE TABLE methods (
@@ -113,6 +200,7 @@ func (d *DB) createSchema() error {
 			output_type VARCHAR NOT NULL,
 			client_streaming BOOLEAN DEFAULT FALSE,
 			server_streaming BOOLEAN DEFAULT FALSE,
+			is_deprecated BOOLEAN DEFAULT FALSE,
 			options JSON
 		)`,
 		`CREATE TABLE extensions (
@@ -123,13 +211,18 @@ func (d *DB) createSchema() error {
 			extendee VARCHAR NOT NULL,
 			type VARCHAR NOT NULL,
 			type_name VARCHAR,
+			label VARCHAR,
+			has_presence BOOLEAN DEFAULT FALSE,
+			is_packed BOOLEAN DEFAULT FALSE,
+			is_deprecated BOOLEAN DEFAULT FALSE,
 			options JSON
 		)`,
 		`CREATE TABLE oneofs (
 			id VARCHAR PRIMARY KEY,
 			name VARCHAR NOT NULL,
 			message VARCHAR NOT NULL,
-			options JSON
+			options JSON,
+			features JSON
 		)`,
 		`CREATE TABLE oneof_fields (
 			oneof_id VARCHAR NOT NULL,
@@ -143,6 +236,91 @@ func (d *DB) createSchema() error {
 			is_weak BOOLEAN DEFAULT FALSE,
 			PRIMARY KEY (file, dependency)
 		)`,
+		`CREATE TABLE http_rules (
+			method VARCHAR NOT NULL,
+			verb VARCHAR NOT NULL,
+			path_template VARCHAR NOT NULL,
+			body VARCHAR,
+			response_body VARCHAR,
+			binding_index INTEGER NOT NULL,
+			PRIMARY KEY (method, binding_index)
+		)`,
+		`CREATE TABLE http_path_params (
+			method VARCHAR NOT NULL,
+			binding_index INTEGER NOT NULL,
+			field_path VARCHAR NOT NULL,
+			pattern VARCHAR NOT NULL,
+			field_id VARCHAR,
+			PRIMARY KEY (method, binding_index, field_path)
+		)`,
+		`CREATE TABLE field_constraints (
+			field_id VARCHAR NOT NULL,
+			rule_family VARCHAR NOT NULL,
+			rule VARCHAR NOT NULL,
+			value VARCHAR,
+			cel_expression VARCHAR,
+			source VARCHAR NOT NULL
+		)`,
+		`CREATE TABLE message_constraints (
+			message VARCHAR NOT NULL,
+			rule_family VARCHAR NOT NULL,
+			rule VARCHAR NOT NULL,
+			value VARCHAR,
+			cel_expression VARCHAR,
+			source VARCHAR NOT NULL
+		)`,
+	}
+
+	// Helper views derived from the tables above
+	views := []string{
+		// enum_ranges summarizes the numbering of each enum: the range of
+		// values used, how many numbers in that range are unused (gaps, as
+		// "n" or "n-m" strings) and how many values are aliases
+		`CREATE VIEW enum_ranges AS
+		WITH steps AS (
+			SELECT enum, number,
+				LEAD(number) OVER (PARTITION BY enum ORDER BY number) AS next_number
+			FROM (SELECT DISTINCT enum, number FROM enum_values)
+		), ranges AS (
+			SELECT
+				enum,
+				MIN(number) AS min_number,
+				MAX(number) AS max_number,
+				COUNT(*) AS distinct_numbers,
+				COALESCE(SUM(next_number - number - 1) FILTER (WHERE next_number > number + 1), 0) AS gap_count,
+				COALESCE(LIST(
+					CASE WHEN next_number = number + 2 THEN CAST(number + 1 AS VARCHAR)
+					ELSE CAST(number + 1 AS VARCHAR) || '-' || CAST(next_number - 1 AS VARCHAR) END
+					ORDER BY number
+				) FILTER (WHERE next_number > number + 1), []) AS gaps
+			FROM steps
+			GROUP BY enum
+		), counts AS (
+			SELECT enum, COUNT(*) AS value_count
+			FROM enum_values
+			GROUP BY enum
+		)
+		SELECT
+			r.enum,
+			r.min_number,
+			r.max_number,
+			c.value_count,
+			r.distinct_numbers,
+			c.value_count - r.distinct_numbers AS alias_count,
+			r.gap_count,
+			r.gaps
+		FROM ranges r
+		JOIN counts c ON c.enum = r.enum`,
+		// all_fields lists the fields of each message together with the
+		// extensions declared for it, which the fields table leaves out
+		`CREATE VIEW all_fields AS
+		SELECT id, name, number, message, type, type_name, label,
+			has_presence, is_packed, is_deprecated, FALSE AS is_extension_field, options
+		FROM fields
+		UNION ALL
+		SELECT full_name, name, number, extendee, type, type_name, label,
+			has_presence, is_packed, is_deprecated, TRUE, options
+		FROM extensions`,
 	}
 
 	for _, schema := range schemas {
@@ -151,22 +329,39 @@ func (d *DB) createSchema() error {
 		}
 	}
 
+	for _, view := range views {
+		if _, err := d.Exec(view); err != nil {
+			return fmt.Errorf("failed to create view: %w", err)
+		}
+	}
+
+	for _, comment := range commentStatements() {
+		if _, err := d.Exec(comment); err != nil {
+			return fmt.Errorf("failed to add schema comment: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // bulkLoader holds appenders for all tables for efficient bulk loading.
 type bulkLoader struct {
-	files        *duckdb.Appender
-	messages     *duckdb.Appender
-	fields       *duckdb.Appender
-	enums        *duckdb.Appender
-	enumValues   *duckdb.Appender
-	services     *duckdb.Appender
-	methods      *duckdb.Appender
-	extensions   *duckdb.Appender
-	oneofs       *duckdb.Appender
-	oneofFields  *duckdb.Appender
-	dependencies *duckdb.Appender
+	files          *duckdb.Appender
+	messages       *duckdb.Appender
+	fields         *duckdb.Appender
+	enums          *duckdb.Appender
+	enumValues     *duckdb.Appender
+	services       *duckdb.Appender
+	methods        *duckdb.Appender
+	extensions     *duckdb.Appender
+	oneofs         *duckdb.Appender
+	oneofFields    *duckdb.Appender
+	dependencies   *duckdb.Appender
+	httpRules      *duckdb.Appender
+	httpPathParams *duckdb.Appender
+
+	fieldConstraints   *duckdb.Appender
+	messageConstraints *duckdb.Appender
 
 	// resolver for extension type resolution
 	resolver linker.Resolver
@@ -241,6 +436,30 @@ func newBulkLoader(conn driver.Conn, resolver linker.Resolver) (*bulkLoader, err
 		return nil, fmt.Errorf("failed to create dependencies appender: %w", err)
 	}
 
+	bl.httpRules, err = duckdb.NewAppenderFromConn(conn, "", "http_rules")
+	if err != nil {
+		bl.Close()
+		return nil, fmt.Errorf("failed to create http_rules appender: %w", err)
+	}
+
+	bl.httpPathParams, err = duckdb.NewAppenderFromConn(conn, "", "http_path_params")
+	if err != nil {
+		bl.Close()
+		return nil, fmt.Errorf("failed to create http_path_params appender: %w", err)
+	}
+
+	bl.fieldConstraints, err = duckdb.NewAppenderFromConn(conn, "", "field_constraints")
+	if err != nil {
+		bl.Close()
+		return nil, fmt.Errorf("failed to create field_constraints appender: %w", err)
+	}
+
+	bl.messageConstraints, err = duckdb.NewAppenderFromConn(conn, "", "message_constraints")
+	if err != nil {
+		bl.Close()
+		return nil, fmt.Errorf("failed to create message_constraints appender: %w", err)
+	}
+
 	return bl, nil
 }
 
@@ -265,6 +484,10 @@ func (bl *bulkLoader) Close() error {
 	closeAppender(bl.oneofs)
 	closeAppender(bl.oneofFields)
 	closeAppender(bl.dependencies)
+	closeAppender(bl.httpRules)
+	closeAppender(bl.httpPathParams)
+	closeAppender(bl.fieldConstraints)
+	closeAppender(bl.messageConstraints)
 
 	return firstErr
 }
@@ -273,6 +496,7 @@ func (bl *bulkLoader) Flush() error {
 	appenders := []*duckdb.Appender{
 		bl.files, bl.messages, bl.fields, bl.enums, bl.enumValues,
 		bl.services, bl.methods, bl.extensions, bl.oneofs, bl.oneofFields, bl.dependencies,
+		bl.httpRules, bl.httpPathParams, bl.fieldConstraints, bl.messageConstraints,
 	}
 	for _, a := range appenders {
 		if a != nil {
@@ -328,6 +552,42 @@ func (bl *bulkLoader) extractOptions(opts proto.Message) any {
 	return result
 }
 
+// isDeprecated reports whether the deprecated option is set on an options
+// message. Every descriptor options type declares the same field, so this is
+// shared across messages, fields, enums, values, services and methods.
+func isDeprecated(opts proto.Message) bool {
+	if opts == nil {
+		return false
+	}
+	msg := opts.ProtoReflect()
+	fd := msg.Descriptor().Fields().ByName("deprecated")
+	if fd == nil || !msg.Has(fd) {
+		return false
+	}
+	return msg.Get(fd).Bool()
+}
+
+// findOption returns the value of the extension option with the given full
+// name (e.g. "google.api.http") if it is set on an options message.
+func findOption(opts proto.Message, name protoreflect.FullName) (protoreflect.FieldDescriptor, protoreflect.Value, bool) {
+	if opts == nil {
+		return nil, protoreflect.Value{}, false
+	}
+
+	var (
+		found protoreflect.FieldDescriptor
+		value protoreflect.Value
+	)
+	opts.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsExtension() && fd.FullName() == name {
+			found, value = fd, v
+			return false
+		}
+		return true
+	})
+	return found, value, found != nil
+}
+
 // valueToInterface converts a protoreflect.Value to a Go any for JSON serialization.
 func valueToInterface(fd protoreflect.FieldDescriptor, v protoreflect.Value, resolver linker.Resolver) any {
 	if fd.IsList() {
@@ -409,8 +669,69 @@ func messageToInterface(msg protoreflect.Message, resolver linker.Resolver) map[
 	return result
 }
 
+// featureNames lists the google.protobuf.FeatureSet fields reported in the
+// features column.
+var featureNames = []protoreflect.Name{
+	"field_presence",
+	"enum_type",
+	"repeated_field_encoding",
+	"utf8_validation",
+	"message_encoding",
+	"json_format",
+}
+
+// resolveFeatures returns the effective edition features for a descriptor as
+// a map of feature name -> enum value name. Values not overridden on the
+// element are inherited from its parents or the edition defaults, so proto2
+// and proto3 files report the semantics their syntax implies.
+func resolveFeatures(d protoreflect.Descriptor) any {
+	featureSet := (*descriptorpb.FeatureSet)(nil).ProtoReflect().Descriptor()
+
+	result := make(map[string]any)
+	for _, name := range featureNames {
+		fd := featureSet.Fields().ByName(name)
+		if fd == nil {
+			continue
+		}
+		v, err := protoutil.ResolveFeature(d, fd)
+		if err != nil {
+			continue
+		}
+		result[string(name)] = scalarToInterface(fd, v, nil)
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// fileEdition returns the edition of a file: "proto2" or "proto3" for
+// syntax-based files, or the edition year (e.g. "2023") for editions files.
+func fileEdition(f linker.File) string {
+	switch f.Syntax() {
+	case protoreflect.Proto3:
+		return "proto3"
+	case protoreflect.Editions:
+		edition := protoutil.ProtoFromFileDescriptor(f).GetEdition()
+		return strings.TrimPrefix(edition.String(), "EDITION_")
+	default:
+		return "proto2"
+	}
+}
+
 // LoadFiles loads parsed proto files into the database using bulk loading.
+// Files that are already loaded, or repeated, are skipped.
 func (d *DB) LoadFiles(files []linker.File) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[string]bool)
+	files = slices.DeleteFunc(slices.Clone(files), func(f linker.File) bool {
+		skip := d.loaded[f.Path()] || seen[f.Path()]
+		seen[f.Path()] = true
+		return skip
+	})
 	if len(files) == 0 {
 		return nil
 	}
@@ -433,7 +754,39 @@ func (d *DB) LoadFiles(files []linker.File) error {
 		}
 	}
 
-	return bl.Flush()
+	if err := bl.Flush(); err != nil {
+		return err
+	}
+	for _, f := range files {
+		d.files = append(d.files, f)
+		d.loaded[f.Path()] = true
+		d.register(f)
+	}
+	return nil
+}
+
+// Files returns the loaded files, in load order.
+func (d *DB) Files() []linker.File {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return slices.Clone(d.files)
+}
+
+// Reset removes every loaded file, leaving the tables empty. Views and
+// tables created by queries are kept.
+func (d *DB) Reset() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, table := range tables {
+		if _, err := d.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	d.files = nil
+	d.loaded = make(map[string]bool)
+	d.descriptors = make(map[string]protoreflect.Descriptor)
+	return nil
 }
 
 func loadFile(bl *bulkLoader, f linker.File) error {
@@ -450,7 +803,7 @@ func loadFile(bl *bulkLoader, f linker.File) error {
 
 	fileOpts := bl.extractOptions(f.Options())
 
-	if err := bl.files.AppendRow(fileName, pkgName, syntax, fileOpts); err != nil {
+	if err := bl.files.AppendRow(fileName, pkgName, syntax, fileEdition(f), fileOpts, resolveFeatures(f)); err != nil {
 		return fmt.Errorf("failed to append file %s: %w", fileName, err)
 	}
 
@@ -505,10 +858,14 @@ func loadMessage(bl *bulkLoader, msg protoreflect.MessageDescriptor, fileName st
 
 	msgOpts := bl.extractOptions(msg.Options())
 
-	if err := bl.messages.AppendRow(fullName, string(msg.Name()), fileName, parent, msg.IsMapEntry(), msgOpts); err != nil {
+	if err := bl.messages.AppendRow(fullName, string(msg.Name()), fileName, parent, msg.IsMapEntry(), isDeprecated(msg.Options()), msgOpts, resolveFeatures(msg)); err != nil {
 		return fmt.Errorf("failed to append message %s: %w", fullName, err)
 	}
 
+	if err := loadMessageConstraints(bl, msg, fullName); err != nil {
+		return err
+	}
+
 	// Oneofs
 	oneofIDs := make(map[int]string)
 	for i := 0; i < msg.Oneofs().Len(); i++ {
@@ -518,7 +875,7 @@ func loadMessage(bl *bulkLoader, msg protoreflect.MessageDescriptor, fileName st
 
 		oneofOpts := bl.extractOptions(oneof.Options())
 
-		if err := bl.oneofs.AppendRow(oneofID, string(oneof.Name()), fullName, oneofOpts); err != nil {
+		if err := bl.oneofs.AppendRow(oneofID, string(oneof.Name()), fullName, oneofOpts, resolveFeatures(oneof)); err != nil {
 			return fmt.Errorf("failed to append oneof %s: %w", oneofID, err)
 		}
 	}
@@ -564,15 +921,7 @@ func loadField(bl *bulkLoader, field protoreflect.FieldDescriptor, msgFullName s
 		typeName = string(field.Enum().FullName())
 	}
 
-	var label any
-	switch field.Cardinality() {
-	case protoreflect.Required:
-		label = "required"
-	case protoreflect.Repeated:
-		label = "repeated"
-	case protoreflect.Optional:
-		label = "optional"
-	}
+	label := labelOf(field)
 
 	isMap := field.IsMap()
 	var mapKeyType, mapValueType any
@@ -593,6 +942,23 @@ func loadField(bl *bulkLoader, field protoreflect.FieldDescriptor, msgFullName s
 		defaultVal = fmt.Sprintf("%v", field.Default().Interface())
 	}
 
+	var oneofName any
+	isSyntheticOneof := false
+	if oneof := field.ContainingOneof(); oneof != nil {
+		oneofName = string(oneof.Name())
+		isSyntheticOneof = oneof.IsSynthetic()
+	}
+
+	// jstype and ctype are only reported when explicitly set
+	var jstype, ctype any
+	descOpts, _ := field.Options().(*descriptorpb.FieldOptions)
+	if descOpts != nil && descOpts.Jstype != nil {
+		jstype = descOpts.GetJstype().String()
+	}
+	if descOpts != nil && descOpts.Ctype != nil {
+		ctype = descOpts.GetCtype().String()
+	}
+
 	fieldOpts := bl.extractOptions(field.Options())
 
 	err := bl.fields.AppendRow(
@@ -610,12 +976,26 @@ func loadField(bl *bulkLoader, field protoreflect.FieldDescriptor, msgFullName s
 		mapValueType,
 		defaultVal,
 		field.JSONName(),
+		field.HasPresence(),
+		field.IsPacked(),
+		isDeprecated(field.Options()),
+		field.Kind() == protoreflect.GroupKind,
+		oneofName,
+		isSyntheticOneof,
+		jstype,
+		ctype,
+		descOpts.GetLazy(),
 		fieldOpts,
+		resolveFeatures(field),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to append field %s: %w", fieldID, err)
 	}
 
+	if err := loadFieldConstraints(bl, field, fieldID); err != nil {
+		return err
+	}
+
 	// Link to oneof
 	if field.ContainingOneof() != nil && !field.ContainingOneof().IsSynthetic() {
 		oneofIdx := field.ContainingOneof().Index()
@@ -637,9 +1017,40 @@ func loadEnum(bl *bulkLoader, enum protoreflect.EnumDescriptor, fileName string,
 		parent = *parentMsg
 	}
 
+	descOpts, _ := enum.Options().(*descriptorpb.EnumOptions)
+
+	reservedNames := make([]string, enum.ReservedNames().Len())
+	for i := range reservedNames {
+		reservedNames[i] = string(enum.ReservedNames().Get(i))
+	}
+
+	// Enum reserved ranges are inclusive on both ends
+	var reservedRanges any
+	if enum.ReservedRanges().Len() > 0 {
+		ranges := make([]map[string]any, enum.ReservedRanges().Len())
+		for i := range ranges {
+			r := enum.ReservedRanges().Get(i)
+			ranges[i] = map[string]any{"start": int32(r[0]), "end": int32(r[1])}
+		}
+		reservedRanges = ranges
+	}
+
 	enumOpts := bl.extractOptions(enum.Options())
 
-	if err := bl.enums.AppendRow(fullName, string(enum.Name()), fileName, parent, enumOpts); err != nil {
+	err := bl.enums.AppendRow(
+		fullName,
+		string(enum.Name()),
+		fileName,
+		parent,
+		enum.IsClosed(),
+		descOpts.GetAllowAlias(),
+		reservedNames,
+		reservedRanges,
+		isDeprecated(enum.Options()),
+		enumOpts,
+		resolveFeatures(enum),
+	)
+	if err != nil {
 		return fmt.Errorf("failed to append enum %s: %w", fullName, err)
 	}
 
@@ -647,9 +1058,16 @@ func loadEnum(bl *bulkLoader, enum protoreflect.EnumDescriptor, fileName string,
 		val := enum.Values().Get(i)
 		valID := fmt.Sprintf("%s.%s", fullName, val.Name())
 
+		// ByNumber returns the first value declared with a number, so any
+		// other value sharing it is an alias of that one
+		var aliasOf any
+		if first := enum.Values().ByNumber(val.Number()); first != nil && first != val {
+			aliasOf = fmt.Sprintf("%s.%s", fullName, first.Name())
+		}
+
 		valOpts := bl.extractOptions(val.Options())
 
-		if err := bl.enumValues.AppendRow(valID, string(val.Name()), int32(val.Number()), fullName, valOpts); err != nil {
+		if err := bl.enumValues.AppendRow(valID, string(val.Name()), int32(val.Number()), fullName, aliasOf, isDeprecated(val.Options()), valOpts); err != nil {
 			return fmt.Errorf("failed to append enum value %s: %w", valID, err)
 		}
 	}
@@ -662,7 +1080,7 @@ func loadService(bl *bulkLoader, svc protoreflect.ServiceDescriptor, fileName st
 
 	svcOpts := bl.extractOptions(svc.Options())
 
-	if err := bl.services.AppendRow(fullName, string(svc.Name()), fileName, svcOpts); err != nil {
+	if err := bl.services.AppendRow(fullName, string(svc.Name()), fileName, isDeprecated(svc.Options()), svcOpts); err != nil {
 		return fmt.Errorf("failed to append service %s: %w", fullName, err)
 	}
 
@@ -680,11 +1098,16 @@ func loadService(bl *bulkLoader, svc protoreflect.ServiceDescriptor, fileName st
 			string(method.Output().FullName()),
 			method.IsStreamingClient(),
 			method.IsStreamingServer(),
+			isDeprecated(method.Options()),
 			methodOpts,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to append method %s: %w", methodFullName, err)
 		}
+
+		if err := loadHTTPRules(bl, method, methodFullName); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -710,11 +1133,30 @@ func loadExtension(bl *bulkLoader, ext protoreflect.ExtensionDescriptor, fileNam
 		string(ext.ContainingMessage().FullName()),
 		ext.Kind().String(),
 		typeName,
+		labelOf(ext),
+		ext.HasPresence(),
+		ext.IsPacked(),
+		isDeprecated(ext.Options()),
 		extOpts,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to append extension %s: %w", fullName, err)
 	}
 
+	// Extensions can carry validation rules like fields, under their full
+	// name
+	return loadFieldConstraints(bl, ext, fullName)
+}
+
+// labelOf returns the label column of a field or extension.
+func labelOf(field protoreflect.FieldDescriptor) any {
+	switch field.Cardinality() {
+	case protoreflect.Required:
+		return "required"
+	case protoreflect.Repeated:
+		return "repeated"
+	case protoreflect.Optional:
+		return "optional"
+	}
 	return nil
 }
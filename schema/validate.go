@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Validation extensions recognized by the constraint loaders. protovalidate
+// (buf.validate) and its predecessor protoc-gen-validate (validate) share the
+// same layout: a rules message with a type-specific submessage per kind.
+const (
+	protovalidateFieldRules   protoreflect.FullName = "buf.validate.field"
+	protovalidateMessageRules protoreflect.FullName = "buf.validate.message"
+	pgvFieldRules             protoreflect.FullName = "validate.rules"
+	pgvMessageDisabled        protoreflect.FullName = "validate.disabled"
+	pgvMessageIgnored         protoreflect.FullName = "validate.ignored"
+)
+
+// Values for the source column of the constraint tables.
+const (
+	sourceProtovalidate = "protovalidate"
+	sourcePGV           = "protoc-gen-validate"
+)
+
+// constraint is a single validation rule. CEL rules carry their id as the
+// rule, their message as the value and the expression separately.
+type constraint struct {
+	family     string
+	rule       string
+	value      any
+	expression any
+	source     string
+}
+
+// loadFieldConstraints records the protovalidate and protoc-gen-validate
+// rules set on a field, one row per rule.
+func loadFieldConstraints(bl *bulkLoader, field protoreflect.FieldDescriptor, fieldID string) error {
+	var constraints []constraint
+	if fd, v, ok := findOption(field.Options(), protovalidateFieldRules); ok && fd.Message() != nil {
+		constraints = append(constraints, rulesConstraints(v.Message(), "field", sourceProtovalidate, bl.resolver)...)
+	}
+	if fd, v, ok := findOption(field.Options(), pgvFieldRules); ok && fd.Message() != nil {
+		constraints = append(constraints, rulesConstraints(v.Message(), "field", sourcePGV, bl.resolver)...)
+	}
+
+	for _, c := range constraints {
+		if err := bl.fieldConstraints.AppendRow(fieldID, c.family, c.rule, c.value, c.expression, c.source); err != nil {
+			return fmt.Errorf("failed to append constraint for field %s: %w", fieldID, err)
+		}
+	}
+	return nil
+}
+
+// loadMessageConstraints records message-level validation rules: the CEL
+// rules and flags of buf.validate.message, and the protoc-gen-validate
+// disabled/ignored options.
+func loadMessageConstraints(bl *bulkLoader, msg protoreflect.MessageDescriptor, fullName string) error {
+	var constraints []constraint
+	if fd, v, ok := findOption(msg.Options(), protovalidateMessageRules); ok && fd.Message() != nil {
+		constraints = append(constraints, rulesConstraints(v.Message(), "message", sourceProtovalidate, bl.resolver)...)
+	}
+	for _, name := range []protoreflect.FullName{pgvMessageDisabled, pgvMessageIgnored} {
+		if fd, v, ok := findOption(msg.Options(), name); ok {
+			constraints = append(constraints, constraint{
+				family: "message",
+				rule:   string(fd.Name()),
+				value:  constraintValue(fd, v, bl.resolver),
+				source: sourcePGV,
+			})
+		}
+	}
+
+	for _, c := range constraints {
+		if err := bl.messageConstraints.AppendRow(fullName, c.family, c.rule, c.value, c.expression, c.source); err != nil {
+			return fmt.Errorf("failed to append constraint for message %s: %w", fullName, err)
+		}
+	}
+	return nil
+}
+
+// rulesConstraints flattens a rules message. Type-specific submessages (e.g.
+// string, int32, repeated) become the rule family of their fields; CEL rules
+// get one row each; anything else is reported under the given family.
+func rulesConstraints(rules protoreflect.Message, family, source string, resolver linker.Resolver) []constraint {
+	var constraints []constraint
+	rules.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Name() == "cel" && fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				constraints = append(constraints, celConstraint(list.Get(i).Message(), source))
+			}
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+			typeFamily := string(fd.Name())
+			v.Message().Range(func(rfd protoreflect.FieldDescriptor, rv protoreflect.Value) bool {
+				constraints = append(constraints, constraint{
+					family: typeFamily,
+					rule:   string(rfd.Name()),
+					value:  constraintValue(rfd, rv, resolver),
+					source: source,
+				})
+				return true
+			})
+		default:
+			constraints = append(constraints, constraint{
+				family: family,
+				rule:   string(fd.Name()),
+				value:  constraintValue(fd, v, resolver),
+				source: source,
+			})
+		}
+		return true
+	})
+	return constraints
+}
+
+// celConstraint converts a buf.validate.Rule message into a constraint.
+func celConstraint(rule protoreflect.Message, source string) constraint {
+	c := constraint{family: "cel", source: source}
+	fields := rule.Descriptor().Fields()
+	if fd := fields.ByName("id"); fd != nil {
+		c.rule = rule.Get(fd).String()
+	}
+	if fd := fields.ByName("message"); fd != nil && rule.Has(fd) {
+		c.value = rule.Get(fd).String()
+	}
+	if fd := fields.ByName("expression"); fd != nil && rule.Has(fd) {
+		c.expression = rule.Get(fd).String()
+	}
+	return c
+}
+
+// constraintValue renders a rule value as text: strings as-is, everything
+// else (numbers, lists, nested rules) as JSON.
+func constraintValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, resolver linker.Resolver) string {
+	value := valueToInterface(fd, v, resolver)
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
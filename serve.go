@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/connor15mcc/pbql-go/pgwire"
+	"github.com/connor15mcc/pbql-go/schema"
+	"github.com/connor15mcc/pbql-go/server"
+	"github.com/spf13/cobra"
+)
+
+const serveHelp = `Load the protos once and serve them over HTTP, so several clients can
+query one index:
+
+  POST /query    SQL in the body (or {"query": ..., "format": ...} as JSON);
+                 results as JSON, CSV (?format=csv or Accept: text/csv) or
+                 Arrow (?format=arrow; only in builds with -tags duckdb_arrow,
+                 others answer 501 Not Implemented)
+  GET  /tables   the tables and views with their descriptions
+  GET  /schema   the tables and views with their columns
+  GET  /health   liveness, with the number of loaded files
+
+The server always runs in --read-only mode: only SELECT statements are
+accepted, and DuckDB cannot access files or install extensions.
+
+With --pgwire, the protos are also served over the PostgreSQL wire
+protocol, for psql and tools such as Grafana or Metabase. Queries still use
+DuckDB's SQL dialect. There is no authentication, so listen on a local
+address. The HTTP API is then only served when --addr is given too.`
+
+func serveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve [flags] <proto-files-or-directories...>",
+		Short: "Serve an HTTP or Postgres API for querying the protos",
+		Long:  serveHelp,
+		Example: `  pbql-go serve --addr :8080 ./protos/
+  curl -d "SELECT full_name FROM services" localhost:8080/query
+  pbql-go serve --pgwire localhost:5433 ./protos/
+  psql -h localhost -p 5433 -c "SELECT full_name FROM services"`,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			addr, _ := cmd.Flags().GetString("addr")
+			pgwireAddr, _ := cmd.Flags().GetString("pgwire")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			verbose, _ := cmd.Flags().GetCount("verbose")
+
+			if len(cmdArgs) == 0 {
+				return fmt.Errorf("at least one proto file or directory is required")
+			}
+			if err := checkInputs(cmdArgs); err != nil {
+				return err
+			}
+			setupLogging(verbose)
+
+			db, err := schema.New()
+			if err != nil {
+				return fmt.Errorf("error initializing database: %v", err)
+			}
+			defer db.Close()
+			if err := db.SetReadOnly(); err != nil {
+				return fmt.Errorf("error: %v", err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := loadProtos(ctx, db, cmdArgs); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			errc := make(chan error, 2)
+			servers := 0
+			if pgwireAddr == "" || cmd.Flags().Changed("addr") {
+				listener, err := net.Listen("tcp", addr)
+				if err != nil {
+					return fmt.Errorf("error listening: %v", err)
+				}
+				fmt.Fprintf(os.Stderr, "Serving %d files on http://%s\n", len(db.Files()), listener.Addr())
+				servers++
+				go func() {
+					errc <- serve(ctx, listener, server.Handler(db, server.Options{Timeout: timeout}))
+				}()
+			}
+			if pgwireAddr != "" {
+				listener, err := net.Listen("tcp", pgwireAddr)
+				if err != nil {
+					cancel()
+					for range servers {
+						<-errc
+					}
+					return fmt.Errorf("error listening: %v", err)
+				}
+				fmt.Fprintf(os.Stderr, "Serving %d files on postgres://%s\n", len(db.Files()), listener.Addr())
+				servers++
+				go func() {
+					errc <- servePgwire(ctx, listener, pgwire.New(db, pgwire.Options{Timeout: timeout}))
+				}()
+			}
+
+			// Stop both servers when either fails
+			var serveErr error
+			for range servers {
+				if err := <-errc; err != nil && serveErr == nil {
+					serveErr = err
+					cancel()
+				}
+			}
+			return serveErr
+		},
+	}
+
+	cmd.Flags().String("addr", ":8080", "Address to serve the HTTP API on")
+	cmd.Flags().String("pgwire", "", "Address to serve the PostgreSQL wire protocol on (e.g. localhost:5433)")
+	cmd.Flags().Duration("timeout", 0, "Cancel queries running longer than this (e.g. 30s); 0 means no limit")
+	return cmd
+}
+
+// serve handles HTTP requests on listener until ctx is done, then lets the
+// requests in flight finish.
+func serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("error serving: %v", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error shutting down: %v", err)
+	}
+	return nil
+}
+
+// servePgwire serves the PostgreSQL wire protocol on listener until ctx is
+// done, then closes the connections.
+func servePgwire(ctx context.Context, listener net.Listener, srv *pgwire.Server) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(listener)
+	}()
+	defer srv.Close()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("error serving: %v", err)
+	case <-ctx.Done():
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build duckdb_arrow
+
+package server
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+var writeArrow = arrowStream
+
+// arrowStream writes a query's results to w in the Arrow IPC stream format.
+func arrowStream(ctx context.Context, w io.Writer, db *sql.DB, query string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(dc any) error {
+		arrow, err := duckdb.NewArrowFromConn(dc.(driver.Conn))
+		if err != nil {
+			return err
+		}
+		reader, err := arrow.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer reader.Release()
+
+		writer := ipc.NewWriter(w, ipc.WithSchema(reader.Schema()))
+		for reader.Next() {
+			if err := writer.Write(reader.RecordBatch()); err != nil {
+				return err
+			}
+		}
+		if err := reader.Err(); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+}
@@ -0,0 +1,12 @@
+//go:build !duckdb_arrow
+
+package server
+
+import (
+	"context"
+	"database/sql"
+	"io"
+)
+
+// writeArrow is nil unless built with the duckdb_arrow tag.
+var writeArrow func(ctx context.Context, w io.Writer, db *sql.DB, query string) error
@@ -0,0 +1,26 @@
+//go:build !duckdb_arrow
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestArrowUnavailable(t *testing.T) {
+	srv := httptest.NewServer(Handler(testDB(t), Options{}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/query?format=arrow", "text/plain", strings.NewReader("SELECT name FROM services"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented || !strings.Contains(string(body), "-tags duckdb_arrow") {
+		t.Errorf("Unexpected response %d: %s", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build duckdb_arrow
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+func TestArrow(t *testing.T) {
+	srv := httptest.NewServer(Handler(testDB(t), Options{}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/query?format=arrow", "text/plain", strings.NewReader("SELECT name FROM services"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != contentTypes[Arrow] {
+		t.Fatalf("Unexpected response %d (%s)", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	reader, err := ipc.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer reader.Release()
+	rows := 0
+	for reader.Next() {
+		rows += int(reader.Record().NumRows())
+	}
+	if reader.Schema().Field(0).Name != "name" || rows == 0 {
+		t.Errorf("Unexpected stream: %v with %d rows", reader.Schema(), rows)
+	}
+}
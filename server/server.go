@@ -0,0 +1,205 @@
+// Package server serves a loaded schema over HTTP, so several clients can
+// query one index instead of each parsing the protos.
+//
+//	POST /query    SQL in the body, or {"query": ..., "format": ...} as JSON;
+//	               results as JSON, CSV or an Arrow IPC stream
+//	GET  /tables   the tables and views with their descriptions
+//	GET  /schema   the tables and views with their columns
+//	GET  /health   liveness, with the number of loaded files
+//
+// Only SELECT statements are accepted; the database should also be made
+// read-only with schema.DB.SetReadOnly, to keep queries away from files.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/connor15mcc/pbql-go/output"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// Arrow is the format streaming results in the Arrow IPC format, available
+// when built with the duckdb_arrow tag.
+const Arrow = "arrow"
+
+// MaxQuerySize bounds the size of a /query request body.
+const MaxQuerySize = 1 << 20
+
+// contentTypes are the media types of the result formats, also accepted in
+// the Accept header to choose one.
+var contentTypes = map[string]string{
+	output.JSON: "application/json",
+	output.CSV:  "text/csv; charset=utf-8",
+	Arrow:       "application/vnd.apache.arrow.stream",
+}
+
+// Options configures the server.
+type Options struct {
+	// Timeout cancels queries running longer than this; 0 means no limit
+	Timeout time.Duration
+}
+
+type server struct {
+	db   *schema.DB
+	opts Options
+}
+
+// Handler returns the HTTP API for a loaded schema.
+func Handler(db *schema.DB, opts Options) http.Handler {
+	s := &server{db: db, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /query", s.handleQuery)
+	mux.HandleFunc("GET /tables", s.handleTables)
+	mux.HandleFunc("GET /schema", s.handleSchema)
+	mux.HandleFunc("GET /health", s.handleHealth)
+	return mux
+}
+
+// queryRequest is the JSON form of a /query request.
+type queryRequest struct {
+	Query  string `json:"query"`
+	Format string `json:"format"`
+}
+
+func (s *server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxQuerySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+
+	req := queryRequest{Query: string(body)}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		req = queryRequest{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no query given"))
+		return
+	}
+	if f := r.URL.Query().Get("format"); f != "" {
+		req.Format = f
+	}
+	if req.Format == "" {
+		req.Format = acceptedFormat(r.Header.Get("Accept"))
+	}
+	if _, ok := contentTypes[req.Format]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q: use json, csv or arrow", req.Format))
+		return
+	}
+	if req.Format == Arrow && writeArrow == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("arrow output is not available in this build: rebuild pbql-go with -tags duckdb_arrow"))
+		return
+	}
+
+	ctx := r.Context()
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	if err := schema.CheckReadOnly(ctx, s.db.DB, req.Query); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, schema.ErrNotReadOnly) {
+			status = http.StatusForbidden
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	if req.Format == Arrow {
+		w.Header().Set("Content-Type", contentTypes[Arrow])
+		if err := writeArrow(ctx, w, s.db.DB, req.Query); err != nil {
+			s.writeQueryError(ctx, w, err)
+		}
+		return
+	}
+
+	// Buffer the results, so that errors can still be reported with a
+	// status
+	var buf bytes.Buffer
+	if err := output.Query(ctx, &buf, s.db.DB, req.Query, req.Format); err != nil {
+		s.writeQueryError(ctx, w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[req.Format])
+	w.Write(buf.Bytes())
+}
+
+// writeQueryError reports a failed query, distinguishing timeouts.
+func (s *server) writeQueryError(ctx context.Context, w http.ResponseWriter, err error) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		writeError(w, http.StatusGatewayTimeout, fmt.Errorf("query timed out after %s", s.opts.Timeout))
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
+}
+
+// acceptedFormat picks the result format named by an Accept header,
+// defaulting to JSON.
+func acceptedFormat(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(part))
+		for format, contentType := range contentTypes {
+			if t, _, _ := mime.ParseMediaType(contentType); t == mediaType {
+				return format
+			}
+		}
+	}
+	return output.JSON
+}
+
+func (s *server) handleTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := schema.Catalog(r.Context(), s.db.DB)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range tables {
+		tables[i].Columns = nil
+	}
+	writeJSON(w, http.StatusOK, tables)
+}
+
+func (s *server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	tables, err := schema.Catalog(r.Context(), s.db.DB)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tables)
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if err := s.db.PingContext(r.Context()); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "files": len(s.db.Files())})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentTypes[output.JSON])
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// writeError responds with {"error": message}.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/parser"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// testDB loads the testdata protos in read-only mode, as serve does.
+func testDB(t *testing.T) *schema.DB {
+	t.Helper()
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	result, err := parser.ParsePaths(context.Background(), []string{"../testdata"}, parser.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.LoadFiles(result.Files); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.SetReadOnly(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return db
+}
+
+// testServer serves the testdata protos on a loopback port.
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(Handler(testDB(t), Options{}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestQuery(t *testing.T) {
+	srv := testServer(t)
+
+	resp, err := http.Post(srv.URL+"/query?format=csv", "text/plain", strings.NewReader("SELECT name FROM services ORDER BY name LIMIT 2"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "name\nAdminService\nInventoryService\n" {
+		t.Errorf("Unexpected response %d: %q", resp.StatusCode, body)
+	}
+
+	resp, err = http.Post(srv.URL+"/query", "application/json", strings.NewReader(`{"query": "SELECT count(*) AS n FROM files WHERE name = 'users.proto'"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var rows []map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&rows)
+	resp.Body.Close()
+	if err != nil || len(rows) != 1 || rows[0]["n"] != float64(1) {
+		t.Errorf("Unexpected rows: %v (%v)", rows, err)
+	}
+}
+
+func TestQueryRejectsWrites(t *testing.T) {
+	srv := testServer(t)
+
+	for _, query := range []string{"DROP TABLE fields", "SELECT 1; DELETE FROM fields", "COPY fields TO 'fields.csv'"} {
+		resp, err := http.Post(srv.URL+"/query", "text/plain", strings.NewReader(query))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "only SELECT statements are allowed") {
+			t.Errorf("Expected %q to be rejected, got %d: %s", query, resp.StatusCode, body)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/health")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var health struct{ Files int }
+	json.NewDecoder(resp.Body).Decode(&health)
+	resp.Body.Close()
+	if health.Files == 0 {
+		t.Errorf("Expected the files to still be loaded")
+	}
+}
+
+func TestTables(t *testing.T) {
+	srv := testServer(t)
+
+	for path, column := range map[string]bool{"/tables": false, "/schema": true} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var tables []schema.Table
+		err = json.NewDecoder(resp.Body).Decode(&tables)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Unexpected error decoding %s: %v", path, err)
+		}
+
+		i := slices.IndexFunc(tables, func(t schema.Table) bool { return t.Name == "fields" })
+		if i < 0 || tables[i].Comment == "" || (len(tables[i].Columns) > 0) != column {
+			t.Errorf("Unexpected fields table from %s: %+v", path, tables)
+		}
+	}
+}
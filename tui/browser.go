@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BrowserWidth is the width of the schema browser sidebar.
+const BrowserWidth = 36
+
+var (
+	browserStyle         = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderRight(true)
+	browserCursorStyle   = lipgloss.NewStyle().Reverse(true)
+	browserKindStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#6C6C6C"))
+	browserTitleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FF00"))
+	browserInactiveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+)
+
+// nodeKind is the kind of proto element a browser node represents.
+type nodeKind int
+
+const (
+	nodePackage nodeKind = iota
+	nodeFile
+	nodeMessage
+	nodeEnum
+	nodeService
+	nodeField
+	nodeEnumValue
+	nodeMethod
+)
+
+// nodeKinds holds, per kind, the tag shown in the tree, the table and key
+// column of the element's own row, and the table and column listing its
+// children for the templated query.
+var nodeKinds = map[nodeKind]struct {
+	tag                     string
+	table, key              string
+	childTable, childColumn string
+}{
+	nodePackage:   {"pkg", "files", "package", "files", "package"},
+	nodeFile:      {"file", "files", "name", "messages", "file"},
+	nodeMessage:   {"msg", "messages", "full_name", "fields", "message"},
+	nodeEnum:      {"enum", "enums", "full_name", "enum_values", "enum"},
+	nodeService:   {"svc", "services", "full_name", "methods", "service"},
+	nodeField:     {"field", "fields", "id", "fields", "id"},
+	nodeEnumValue: {"value", "enum_values", "id", "enum_values", "id"},
+	nodeMethod:    {"rpc", "methods", "full_name", "methods", "full_name"},
+}
+
+type treeNode struct {
+	kind     nodeKind
+	label    string
+	key      string
+	depth    int
+	expanded bool
+	children []*treeNode
+}
+
+// detailQuery selects the node's own row(s).
+func (n *treeNode) detailQuery() string {
+	k := nodeKinds[n.kind]
+	return fmt.Sprintf("SELECT * FROM %s WHERE %s = %s", k.table, k.key, quoteLiteral(n.key))
+}
+
+// templateQuery is the query offered for insertion into the editor: the
+// node's children, e.g. the fields of a message.
+func (n *treeNode) templateQuery() string {
+	k := nodeKinds[n.kind]
+	return fmt.Sprintf("SELECT * FROM %s WHERE %s = %s", k.childTable, k.childColumn, quoteLiteral(n.key))
+}
+
+// browser is a collapsible tree of packages, files, top-level and nested
+// definitions and their members, built from the loaded tables.
+type browser struct {
+	roots   []*treeNode
+	visible []*treeNode
+	cursor  int
+	offset  int
+	height  int
+	focused bool
+}
+
+func newBrowser(db *sql.DB) (*browser, error) {
+	b := &browser{}
+
+	packages := make(map[string]*treeNode)
+	files := make(map[string]*treeNode)
+	elements := make(map[string]*treeNode)
+
+	add := func(parent *treeNode, child *treeNode) {
+		if parent == nil {
+			return
+		}
+		child.depth = parent.depth + 1
+		parent.children = append(parent.children, child)
+	}
+
+	err := queryEach(db, "SELECT name, COALESCE(package, '') FROM files ORDER BY package, name", func(s []string) {
+		pkg, ok := packages[s[1]]
+		if !ok {
+			label := s[1]
+			if label == "" {
+				label = "(no package)"
+			}
+			pkg = &treeNode{kind: nodePackage, label: label, key: s[1]}
+			packages[s[1]] = pkg
+			b.roots = append(b.roots, pkg)
+		}
+		file := &treeNode{kind: nodeFile, label: s[0], key: s[0]}
+		files[s[0]] = file
+		add(pkg, file)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Nested definitions sort after their parents, so parents exist first
+	defs := []struct {
+		kind  nodeKind
+		query string
+	}{
+		{nodeMessage, "SELECT full_name, name, file, COALESCE(parent_message, '') FROM messages ORDER BY full_name"},
+		{nodeEnum, "SELECT full_name, name, file, COALESCE(parent_message, '') FROM enums ORDER BY full_name"},
+		{nodeService, "SELECT full_name, name, file, '' FROM services ORDER BY full_name"},
+	}
+	for _, def := range defs {
+		err := queryEach(db, def.query, func(s []string) {
+			node := &treeNode{kind: def.kind, label: s[1], key: s[0]}
+			elements[s[0]] = node
+			if parent, ok := elements[s[3]]; ok {
+				add(parent, node)
+			} else {
+				add(files[s[2]], node)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	members := []struct {
+		kind  nodeKind
+		query string
+	}{
+		{nodeField, "SELECT id, name || ' = ' || number, message FROM fields ORDER BY message, number"},
+		{nodeEnumValue, "SELECT id, name || ' = ' || number, enum FROM enum_values ORDER BY enum, number"},
+		{nodeMethod, "SELECT full_name, name, service FROM methods ORDER BY service, full_name"},
+	}
+	for _, member := range members {
+		err := queryEach(db, member.query, func(s []string) {
+			add(elements[s[2]], &treeNode{kind: member.kind, label: s[1], key: s[0]})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b.refresh()
+	return b, nil
+}
+
+// queryEach runs a query and calls fn with each row's columns as strings.
+func queryEach(db *sql.DB, query string, fn func([]string)) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		values := make([]string, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		fn(values)
+	}
+	return rows.Err()
+}
+
+// refresh recomputes the visible (expanded) nodes.
+func (b *browser) refresh() {
+	b.visible = b.visible[:0]
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			b.visible = append(b.visible, n)
+			if n.expanded {
+				walk(n.children)
+			}
+		}
+	}
+	walk(b.roots)
+	b.clamp()
+}
+
+func (b *browser) selected() *treeNode {
+	if b.cursor < 0 || b.cursor >= len(b.visible) {
+		return nil
+	}
+	return b.visible[b.cursor]
+}
+
+// handleKey applies a navigation key and reports whether it was used.
+func (b *browser) handleKey(key string) bool {
+	node := b.selected()
+	switch key {
+	case "up", "k":
+		b.cursor--
+	case "down", "j":
+		b.cursor++
+	case "pgup":
+		b.cursor -= b.height - 1
+	case "pgdown":
+		b.cursor += b.height - 1
+	case "right", "l":
+		if node == nil || len(node.children) == 0 {
+			break
+		}
+		if node.expanded {
+			b.cursor++
+		} else {
+			node.expanded = true
+			b.refresh()
+		}
+	case " ":
+		if node != nil && len(node.children) > 0 {
+			node.expanded = !node.expanded
+			b.refresh()
+		}
+	case "left", "h":
+		if node == nil {
+			break
+		}
+		if node.expanded {
+			node.expanded = false
+			b.refresh()
+			break
+		}
+		// Jump to the parent node
+		for i := b.cursor - 1; i >= 0; i-- {
+			if b.visible[i].depth < node.depth {
+				b.cursor = i
+				break
+			}
+		}
+	default:
+		return false
+	}
+	b.clamp()
+	return true
+}
+
+// clamp keeps the cursor on a node and scrolls to it. The title takes one
+// of the browser's lines.
+func (b *browser) clamp() {
+	b.cursor = max(0, min(b.cursor, len(b.visible)-1))
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.height > 1 && b.cursor >= b.offset+b.height-1 {
+		b.offset = b.cursor - b.height + 2
+	}
+}
+
+func (b *browser) View() string {
+	var lines []string
+	title := "Schema"
+	if !b.focused {
+		title = browserInactiveStyle.Render(title + " (Ctrl+B)")
+	} else {
+		title = browserTitleStyle.Render(title)
+	}
+	lines = append(lines, title)
+
+	b.height = max(1, b.height)
+	for i := b.offset; i < len(b.visible) && i < b.offset+b.height-1; i++ {
+		n := b.visible[i]
+		marker := "  "
+		if len(n.children) > 0 {
+			marker = "▸ "
+			if n.expanded {
+				marker = "▾ "
+			}
+		}
+		line := strings.Repeat("  ", n.depth) + marker + n.label
+		line = pad(line, BrowserWidth-len(nodeKinds[n.kind].tag)-3)
+		if i == b.cursor && b.focused {
+			line = browserCursorStyle.Render(line)
+		}
+		lines = append(lines, line+" "+browserKindStyle.Render(nodeKinds[n.kind].tag))
+	}
+
+	for len(lines) < b.height {
+		lines = append(lines, "")
+	}
+	return browserStyle.Width(BrowserWidth).Render(strings.Join(lines, "\n"))
+}
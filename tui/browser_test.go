@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBrowserQueriesQuoteKeys(t *testing.T) {
+	// File names can contain quotes
+	n := &treeNode{kind: nodeFile, key: "it's.proto"}
+	if got, want := n.detailQuery(), "SELECT * FROM files WHERE name = 'it''s.proto'"; got != want {
+		t.Errorf("detailQuery() = %q, want %q", got, want)
+	}
+	if got, want := n.templateQuery(), "SELECT * FROM messages WHERE file = 'it''s.proto'"; got != want {
+		t.Errorf("templateQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestBrowserScrollsToCursor(t *testing.T) {
+	b := &browser{height: 5, focused: true}
+	for i := range 10 {
+		b.visible = append(b.visible, &treeNode{kind: nodeMessage, label: fmt.Sprintf("m%d", i)})
+	}
+
+	// The title takes a line, so four nodes are shown
+	for _, key := range []string{"down", "down", "down", "down", "pgdown", "pgdown", "up", "pgup", "pgup"} {
+		b.handleKey(key)
+		if b.cursor < b.offset || b.cursor >= b.offset+b.height-1 {
+			t.Fatalf("After %s, cursor %d is outside the rows shown from %d", key, b.cursor, b.offset)
+		}
+		if !strings.Contains(b.View(), fmt.Sprintf("m%d ", b.cursor)) {
+			t.Errorf("After %s, node m%d is not shown", key, b.cursor)
+		}
+	}
+}
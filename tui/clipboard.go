@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/connor15mcc/pbql-go/output"
+)
+
+// lastResult returns the last result set, in the grid's order when it is
+// shown.
+func (m Model) lastResult() ([]string, [][]any, bool) {
+	if m.grid != nil {
+		return m.grid.columns, m.grid.values, true
+	}
+	return m.resultCols, m.resultValues, m.resultCols != nil
+}
+
+// copyCell copies the value of the selected cell, with JSON values
+// indented as in the detail pane.
+func (m *Model) copyCell() tea.Cmd {
+	if len(m.grid.values) == 0 {
+		m.status = "Nothing to copy"
+		return nil
+	}
+	m.status = fmt.Sprintf("Copied %s", m.grid.columns[m.grid.cursorCol])
+	return m.copyToClipboard(detailValue(m.grid.values[m.grid.cursorRow][m.grid.cursorCol]))
+}
+
+// copyRows copies the selected row, or every row, with a header in the
+// clipboard format.
+func (m *Model) copyRows(selected bool) tea.Cmd {
+	values := m.grid.values
+	if selected && len(values) > 0 {
+		values = values[m.grid.cursorRow : m.grid.cursorRow+1]
+	}
+	var buf bytes.Buffer
+	if err := output.Values(&buf, m.grid.columns, values, m.clipboardFormat); err != nil {
+		m.status = fmt.Sprintf("Copy failed: %v", err)
+		return nil
+	}
+	m.status = fmt.Sprintf("Copied %s as %s", rowCount(len(values)), m.clipboardFormat)
+	return m.copyToClipboard(buf.String())
+}
+
+// clipboardMsg reports the outcome of a copy to the clipboard.
+type clipboardMsg struct {
+	err error
+}
+
+// terminalWriter is the program's output. Writes are serialized, so that
+// escape sequences sent outside the renderer cannot interleave with its
+// frames; the embedded file keeps the terminal detectable.
+type terminalWriter struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (t *terminalWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.File.Write(p)
+}
+
+// copyToClipboard sets the clipboard through the terminal with an OSC 52
+// escape sequence, which also works over SSH. tmux and screen need it
+// wrapped to pass it on.
+func (m *Model) copyToClipboard(text string) tea.Cmd {
+	w := m.terminal
+	return func() tea.Msg {
+		if w == nil {
+			return clipboardMsg{errors.New("no terminal to copy through")}
+		}
+		seq := osc52.New(text)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(w)
+		return clipboardMsg{err}
+	}
+}
+
+// exportResults writes the last result set to a file, in the format given
+// by its extension.
+func (m *Model) exportResults(path string) {
+	cols, values, ok := m.lastResult()
+	if !ok {
+		m.status = "No results to export"
+		return
+	}
+	format, ok := output.FormatForFile(path)
+	if !ok {
+		m.status = fmt.Sprintf("Cannot export to %s: use a .csv, .tsv, .json, .md or .txt file", path)
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to open %s: %v", path, err)
+		return
+	}
+	err = output.Values(f, cols, values, format)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to write %s: %v", path, err)
+		return
+	}
+	m.status = fmt.Sprintf("Exported %s to %s", rowCount(len(values)), path)
+}
+
+func rowCount(n int) string {
+	if n == 1 {
+		return "1 row"
+	}
+	return fmt.Sprintf("%d rows", n)
+}
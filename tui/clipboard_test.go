@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCopyToClipboard(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+
+	f, err := os.CreateTemp(t.TempDir(), "terminal")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	m := &Model{terminal: &terminalWriter{File: f}}
+	if msg := m.copyToClipboard("hello")().(clipboardMsg); msg.err != nil {
+		t.Fatalf("Unexpected error: %v", msg.err)
+	}
+	data, _ := os.ReadFile(f.Name())
+	// "hello" is "aGVsbG8=" in base64
+	if !strings.HasPrefix(string(data), "\x1b]52;c;aGVsbG8=") {
+		t.Errorf("Unexpected sequence %q", data)
+	}
+
+	// Without a terminal, the copy fails instead of writing elsewhere
+	m = &Model{}
+	if msg := m.copyToClipboard("hello")().(clipboardMsg); msg.err == nil {
+		t.Errorf("Expected an error without a terminal")
+	}
+}
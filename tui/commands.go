@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/connor15mcc/pbql-go/parser"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// tablesQuery lists the tables, views and macros that can be queried.
+const tablesQuery = `SELECT table_name AS name,
+	CASE table_type WHEN 'BASE TABLE' THEN 'table' WHEN 'VIEW' THEN 'view' ELSE lower(table_type) END AS type
+FROM information_schema.tables
+UNION ALL
+SELECT DISTINCT function_name, replace(function_type, '_', ' ')
+FROM duckdb_functions()
+WHERE function_type IN ('macro', 'table_macro') AND NOT internal
+ORDER BY name`
+
+// columnsQuery lists the columns of a table or view.
+const columnsQuery = `SELECT column_name, data_type, is_nullable, column_comment AS description
+FROM information_schema.columns
+WHERE table_name = %s
+ORDER BY ordinal_position`
+
+// schemaQuery lists the columns of every table and view, with their types
+// and descriptions.
+const schemaQuery = `SELECT c.table_name AS "table", c.column_name AS "column", c.data_type AS type, c.column_comment AS description
+FROM information_schema.columns c
+JOIN information_schema.tables t USING (table_catalog, table_schema, table_name)
+ORDER BY t.table_type = 'VIEW', c.table_name, c.ordinal_position`
+
+// runCommandQuery runs a query on behalf of a dot-command.
+func (m Model) runCommandQuery(query string) (Model, tea.Cmd) {
+	if m.running != nil {
+		m.status = "A query is already running (Esc to cancel)"
+		return m, nil
+	}
+	return m, m.startQuery(query, false, false)
+}
+
+// readScript runs the statements of a SQL file in order. They are checked
+// like queries typed in the editor.
+func (m Model) readScript(path string) (Model, tea.Cmd) {
+	if m.running != nil {
+		m.status = "A query is already running (Esc to cancel)"
+		return m, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.showMessage("Error", fmt.Sprintf("Failed to read %s: %v", path, err))
+		m.recalculateLayout()
+		return m, nil
+	}
+	return m, m.startQuery(string(data), false, true)
+}
+
+// loadProtos parses proto files or directories in the background and adds
+// them to the database. They are parsed again by .reload.
+func (m Model) loadProtos(paths []string) (Model, tea.Cmd) {
+	if m.running != nil {
+		m.status = "A query is already running (Esc to cancel)"
+		return m, nil
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			m.showMessage("Error", err.Error())
+			m.recalculateLayout()
+			return m, nil
+		}
+	}
+
+	m.loaded = append(m.loaded, paths...)
+	db := m.schema
+	cmd := m.startTask("Loading protos", func(ctx context.Context) queryResultMsg {
+		before := len(db.Files())
+		result, err := parser.ParsePaths(ctx, paths, parser.Options{})
+		if err != nil {
+			return queryResultMsg{err: err}
+		}
+		if err := db.LoadFiles(result.Files); err != nil {
+			return queryResultMsg{err: err}
+		}
+		return queryResultMsg{status: loadStatus("Loaded", len(db.Files())-before, len(result.Errors))}
+	})
+	m.running.reloads = true
+	return m, cmd
+}
+
+// reloadProtos clears the database and parses the inputs (the command-line
+// roots and anything added with .load) again in the background. Tables
+// and views created by queries are kept.
+func (m Model) reloadProtos() (Model, tea.Cmd) {
+	if m.running != nil {
+		m.status = "A query is already running (Esc to cancel)"
+		return m, nil
+	}
+
+	paths := append(append([]string{}, m.roots...), m.loaded...)
+	db := m.schema
+	cmd := m.startTask("Reloading protos", func(ctx context.Context) queryResultMsg {
+		result, err := parser.ParsePaths(ctx, paths, parser.Options{})
+		if err != nil {
+			return queryResultMsg{err: err}
+		}
+		if err := db.Reset(); err != nil {
+			return queryResultMsg{err: err}
+		}
+		if err := db.LoadFiles(result.Files); err != nil {
+			return queryResultMsg{err: err}
+		}
+		return queryResultMsg{status: loadStatus("Reloaded", len(db.Files()), len(result.Errors))}
+	})
+	m.running.reloads = true
+	return m, cmd
+}
+
+func loadStatus(verb string, files, errors int) string {
+	status := fmt.Sprintf("%s %d files", verb, files)
+	if errors > 0 {
+		status += fmt.Sprintf(" (%d parse errors, see -v)", errors)
+	}
+	return status
+}
+
+// setOutput redirects query results to a file, truncating it, or back to
+// the results pane when path is empty or "stdout".
+func (m *Model) setOutput(path string) {
+	if m.output != nil {
+		m.output.Close()
+		m.output = nil
+	}
+	if path == "" || path == "stdout" {
+		m.showMessage("Status", "Results are shown on screen")
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		m.showMessage("Error", fmt.Sprintf("Failed to open %s: %v", path, err))
+		return
+	}
+	m.output = f
+	m.showMessage("Status", fmt.Sprintf("Writing results to %s", path))
+}
+
+// describe shows the columns of a table or view, or the definition of a
+// proto element.
+func (m Model) describe(name string) (Model, tea.Cmd) {
+	var exists bool
+	err := m.db.QueryRow("SELECT count(*) > 0 FROM information_schema.tables WHERE table_name = ?", name).Scan(&exists)
+	if err == nil && exists {
+		return m.runCommandQuery(fmt.Sprintf(columnsQuery, quoteLiteral(name)))
+	}
+
+	if desc := m.schema.Descriptor(name); desc != nil {
+		m.setResults(schema.Definition(desc))
+	} else {
+		m.showMessage("Error", fmt.Sprintf("No table or proto element named %s", name))
+	}
+	m.recalculateLayout()
+	return m, nil
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
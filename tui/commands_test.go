@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// runCommand runs a dot-command, waiting for the query it starts.
+func runCommand(t *testing.T, m Model, command string) Model {
+	t.Helper()
+	m, cmd := m.handleCommand(command)
+	if cmd == nil {
+		return m
+	}
+	// The query runs first, batched with the spinner
+	msg := cmd().(tea.BatchMsg)[0]().(queryResultMsg)
+	m.handleQueryResult(msg)
+	return m
+}
+
+func TestReadScriptReadOnly(t *testing.T) {
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+	if err := db.SetReadOnly(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "script.sql")
+	if err := os.WriteFile(path, []byte("CREATE TABLE notes (note VARCHAR);\nSELECT 1;\n"), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m := runCommand(t, initialModel(db, Options{}), ".read "+path)
+	if m.status != schema.ErrNotReadOnly.Error() {
+		t.Errorf("Expected the script to be rejected, got status %q", m.status)
+	}
+	var n int
+	db.QueryRow("SELECT count(*) FROM information_schema.tables WHERE table_name = 'notes'").Scan(&n)
+	if n != 0 {
+		t.Errorf("Expected no notes table to be created")
+	}
+
+	// Our own queries still run
+	if m = runCommand(t, m, ".tables"); m.grid == nil {
+		t.Errorf("Expected .tables to show results, got status %q", m.status)
+	}
+}
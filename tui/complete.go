@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"database/sql"
+	"regexp"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sqlKeywords are offered as completions outside string literals.
+var sqlKeywords = []string{
+	"SELECT", "FROM", "WHERE", "JOIN", "LEFT", "RIGHT", "INNER", "OUTER", "FULL",
+	"CROSS", "ON", "USING", "AS", "AND", "OR", "NOT", "IN", "IS", "NULL", "LIKE",
+	"ILIKE", "BETWEEN", "EXISTS", "CASE", "WHEN", "THEN", "ELSE", "END", "GROUP",
+	"BY", "ORDER", "HAVING", "LIMIT", "OFFSET", "DISTINCT", "UNION", "ALL",
+	"EXCEPT", "INTERSECT", "WITH", "RECURSIVE", "ASC", "DESC", "COUNT", "SUM",
+	"MIN", "MAX", "AVG", "TRUE", "FALSE", "DESCRIBE", "SUMMARIZE",
+}
+
+// tableRefPattern matches table references ("FROM fields f", "JOIN
+// messages AS m") so columns of the referenced tables can be offered.
+var tableRefPattern = regexp.MustCompile(`(?i)\b(?:from|join)\s+([a-z_][a-z0-9_]*)(?:\s+(?:as\s+)?([a-z_][a-z0-9_]*))?`)
+
+// completer suggests SQL keywords, table and column names, and proto full
+// names inside string literals. Its data is read from the database once and
+// refreshed when the loaded protos change.
+type completer struct {
+	keywords   map[string]bool
+	tables     []string
+	columns    map[string][]string
+	protoNames []string
+}
+
+// completerMsg delivers a completer rebuilt in the background.
+type completerMsg struct {
+	completer *completer
+}
+
+// loadCompleter rebuilds the completer off the update loop, as reading the
+// catalog can take a moment with many protos loaded.
+func loadCompleter(db *sql.DB) tea.Cmd {
+	return func() tea.Msg {
+		return completerMsg{newCompleter(db)}
+	}
+}
+
+func newCompleter(db *sql.DB) *completer {
+	c := &completer{
+		keywords: make(map[string]bool, len(sqlKeywords)),
+		columns:  make(map[string][]string),
+	}
+	for _, kw := range sqlKeywords {
+		c.keywords[kw] = true
+	}
+
+	rows, err := db.Query(`SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = 'main' ORDER BY table_name, ordinal_position`)
+	if err == nil {
+		for rows.Next() {
+			var tableName, columnName string
+			if rows.Scan(&tableName, &columnName) != nil {
+				continue
+			}
+			if _, ok := c.columns[tableName]; !ok {
+				c.tables = append(c.tables, tableName)
+			}
+			c.columns[tableName] = append(c.columns[tableName], columnName)
+		}
+		rows.Close()
+	}
+
+	rows, err = db.Query(`SELECT full_name FROM messages
+		UNION SELECT full_name FROM enums
+		UNION SELECT full_name FROM services
+		ORDER BY full_name`)
+	if err == nil {
+		for rows.Next() {
+			var name string
+			if rows.Scan(&name) == nil {
+				c.protoNames = append(c.protoNames, name)
+			}
+		}
+		rows.Close()
+	}
+
+	return c
+}
+
+// complete returns the word being completed at offset in text and the
+// candidates that could replace it.
+func (c *completer) complete(text string, offset int) (string, []string) {
+	// Only the statement around the cursor matters
+	start := strings.LastIndex(text[:offset], ";") + 1
+	end := len(text)
+	if i := strings.Index(text[offset:], ";"); i >= 0 {
+		end = offset + i
+	}
+	statement := text[start:end]
+	before := text[start:offset]
+
+	// Inside a string literal, complete proto full names
+	if strings.Count(before, "'")%2 == 1 {
+		prefix := before[strings.LastIndex(before, "'")+1:]
+		return prefix, matchPrefix(c.protoNames, prefix, false)
+	}
+
+	wordStart := len(before)
+	for wordStart > 0 && isWordByte(before[wordStart-1]) {
+		wordStart--
+	}
+	word := before[wordStart:]
+
+	refs := c.tableRefs(statement)
+
+	// "alias.col" completes columns of the aliased table
+	if qualifier, prefix, ok := strings.Cut(word, "."); ok {
+		table, ok := refs[strings.ToLower(qualifier)]
+		if !ok {
+			return prefix, nil
+		}
+		return prefix, matchPrefix(c.columns[table], prefix, true)
+	}
+
+	if word == "" {
+		return word, nil
+	}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(names []string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				candidates = append(candidates, name)
+			}
+		}
+	}
+	for _, table := range refs {
+		add(matchPrefix(c.columns[table], word, true))
+	}
+	add(matchPrefix(c.tables, word, true))
+
+	keywords := matchPrefix(sqlKeywords, word, true)
+	if strings.ToLower(word) == word {
+		for i, kw := range keywords {
+			keywords[i] = strings.ToLower(kw)
+		}
+	}
+	add(keywords)
+
+	return word, candidates
+}
+
+// tableRefs maps table names and aliases referenced in a statement to the
+// table they name. Only tables known to the completer are included.
+func (c *completer) tableRefs(statement string) map[string]string {
+	refs := make(map[string]string)
+	for _, match := range tableRefPattern.FindAllStringSubmatch(statement, -1) {
+		table := strings.ToLower(match[1])
+		if _, ok := c.columns[table]; !ok {
+			continue
+		}
+		refs[table] = table
+		if alias := strings.ToLower(match[2]); alias != "" && !c.keywords[strings.ToUpper(alias)] {
+			refs[alias] = table
+		}
+	}
+	return refs
+}
+
+// matchPrefix returns the names starting with prefix, sorted.
+func matchPrefix(names []string, prefix string, ignoreCase bool) []string {
+	var matches []string
+	for _, name := range names {
+		if ignoreCase {
+			if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+				matches = append(matches, name)
+			}
+		} else if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// commonPrefix returns the longest prefix shared by all candidates, compared
+// case-insensitively and spelled as in the first candidate.
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		n := 0
+		for n < len(prefix) && n < len(c) && strings.EqualFold(prefix[n:n+1], c[n:n+1]) {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '.' ||
+		(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
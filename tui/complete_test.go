@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+func testCompleter() *completer {
+	c := &completer{
+		keywords: make(map[string]bool),
+		tables:   []string{"fields", "files", "messages"},
+		columns: map[string][]string{
+			"fields":   {"id", "name", "message", "type"},
+			"files":    {"name", "package"},
+			"messages": {"full_name", "name", "file"},
+		},
+		protoNames: []string{"example.api.User", "example.api.UserService", "example.users.User"},
+	}
+	for _, kw := range sqlKeywords {
+		c.keywords[kw] = true
+	}
+	return c
+}
+
+// completeAt completes at the | in text.
+func completeAt(c *completer, text string) (string, []string) {
+	offset := strings.Index(text, "|")
+	return c.complete(strings.Replace(text, "|", "", 1), offset)
+}
+
+func TestCompleteTablesAndKeywords(t *testing.T) {
+	c := testCompleter()
+
+	word, candidates := completeAt(c, "SELECT * FROM fi|")
+	if word != "fi" || !slices.Equal(candidates, []string{"fields", "files"}) {
+		t.Errorf("Unexpected completion %q %v", word, candidates)
+	}
+
+	// Keywords follow the case of the word
+	if _, candidates := completeAt(c, "sel|"); !slices.Equal(candidates, []string{"select"}) {
+		t.Errorf("Expected a lowercase keyword, got %v", candidates)
+	}
+	if _, candidates := completeAt(c, "SEL|"); !slices.Equal(candidates, []string{"SELECT"}) {
+		t.Errorf("Expected an uppercase keyword, got %v", candidates)
+	}
+
+	if _, candidates := completeAt(c, "SELECT | FROM fields"); candidates != nil {
+		t.Errorf("Expected no candidates without a word, got %v", candidates)
+	}
+}
+
+func TestCompleteColumns(t *testing.T) {
+	c := testCompleter()
+
+	// Columns of the tables in the statement come first
+	_, candidates := completeAt(c, "SELECT na| FROM messages")
+	if len(candidates) == 0 || candidates[0] != "name" {
+		t.Errorf("Expected the name column, got %v", candidates)
+	}
+	if _, candidates := completeAt(c, "SELECT ty| FROM messages"); slices.Contains(candidates, "type") {
+		t.Errorf("Expected no columns of tables not in the statement, got %v", candidates)
+	}
+
+	// Aliases qualify columns
+	word, candidates := completeAt(c, "SELECT f.| FROM fields f JOIN messages AS m ON m.full_name = f.message")
+	if word != "" || !slices.Equal(candidates, []string{"id", "message", "name", "type"}) {
+		t.Errorf("Unexpected completion %q %v", word, candidates)
+	}
+	if _, candidates := completeAt(c, "SELECT m.f| FROM fields f JOIN messages AS m ON true"); !slices.Equal(candidates, []string{"file", "full_name"}) {
+		t.Errorf("Unexpected alias completion %v", candidates)
+	}
+
+	// Only the statement at the cursor is considered
+	if _, candidates := completeAt(c, "SELECT * FROM files; SELECT pa| FROM fields"); slices.Contains(candidates, "package") {
+		t.Errorf("Expected no columns of other statements, got %v", candidates)
+	}
+}
+
+func TestCompleteProtoNames(t *testing.T) {
+	c := testCompleter()
+
+	word, candidates := completeAt(c, "SELECT * FROM messages WHERE full_name = 'example.api.U|'")
+	if word != "example.api.U" || !slices.Equal(candidates, []string{"example.api.User", "example.api.UserService"}) {
+		t.Errorf("Unexpected completion %q %v", word, candidates)
+	}
+	if got := commonPrefix(candidates); got != "example.api.User" {
+		t.Errorf("commonPrefix(%v) = %q", candidates, got)
+	}
+}
+
+func TestNewCompleter(t *testing.T) {
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	c := newCompleter(db.DB)
+	if !slices.Contains(c.tables, "fields") || !slices.Contains(c.columns["fields"], "has_presence") {
+		t.Errorf("Expected the catalog's tables and columns, got %v", c.tables)
+	}
+}
+
+func TestCompleteInput(t *testing.T) {
+	for _, tt := range []struct {
+		text   string
+		cursor int
+		want   string
+	}{
+		// The candidate's case replaces what was typed
+		{"SELECT * FROM FIE", -1, "SELECT * FROM fields"},
+		{"sel", -1, "select"},
+		// The whole word under the cursor is replaced
+		{"SELECT nam FROM fields", len("SELECT na"), "SELECT name FROM fields"},
+		{"SELECT * FROM Fiel", len("SELECT * FROM Fie"), "SELECT * FROM fields"},
+		// Several candidates only complete their common prefix
+		{"SELECT * FROM FI", -1, "SELECT * FROM FI"},
+		{"SELECT * FROM messages WHERE full_name = 'example.api.Us", -1, "SELECT * FROM messages WHERE full_name = 'example.api.User"},
+	} {
+		m := &Model{input: newEditor(), completer: testCompleter()}
+		m.input.SetValue(tt.text)
+		if tt.cursor >= 0 {
+			m.input.SetCursor(tt.cursor)
+		}
+		m.completeInput()
+		if got := m.input.Value(); got != tt.want {
+			t.Errorf("Completing %q at %d: expected %q, got %q", tt.text, tt.cursor, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tokenKind classifies a byte of the query for syntax highlighting.
+type tokenKind int
+
+const (
+	tokenPlain tokenKind = iota
+	tokenKeyword
+	tokenString
+	tokenNumber
+	tokenComment
+	tokenError
+)
+
+var (
+	tokenStyles = map[tokenKind]lipgloss.Style{
+		tokenPlain:   lipgloss.NewStyle(),
+		tokenKeyword: lipgloss.NewStyle().Foreground(lipgloss.Color("#5FAFFF")).Bold(true),
+		tokenString:  lipgloss.NewStyle().Foreground(lipgloss.Color("#87D787")),
+		tokenNumber:  lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF5F")),
+		tokenComment: lipgloss.NewStyle().Foreground(lipgloss.Color("#6C6C6C")).Italic(true),
+		tokenError:   lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Background(lipgloss.Color("#AF0000")).Underline(true),
+	}
+	cursorStyle      = lipgloss.NewStyle().Reverse(true)
+	lineNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#6C6C6C"))
+	errorStatusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F"))
+)
+
+// editorPrompt and lineNumberWidth mirror the textarea gutter, so the
+// highlighted editor lines up with the textarea it replaces.
+const (
+	editorPrompt    = "┃ "
+	lineNumberWidth = 4
+)
+
+// classifySQL assigns a token kind to every byte of a query. If errOffset is
+// a valid offset, the token containing it is marked as an error.
+func classifySQL(text string, errOffset int) []tokenKind {
+	kinds := make([]tokenKind, len(text))
+	mark := func(start, end int, kind tokenKind) {
+		for i := start; i < end && i < len(kinds); i++ {
+			kinds[i] = kind
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '-' && strings.HasPrefix(text[i:], "--"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			mark(i, i+end, tokenComment)
+			i += end
+		case c == '\'':
+			end := i + 1
+			for end < len(text) {
+				if text[end] == '\'' {
+					// '' is an escaped quote inside a literal
+					if end+1 < len(text) && text[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			mark(i, end, tokenString)
+			i = end
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(text) && (isWordByte(text[end]) && text[end] != '_') {
+				end++
+			}
+			mark(i, end, tokenNumber)
+			i = end
+		case isIdentByte(c):
+			end := i
+			for end < len(text) && isIdentByte(text[end]) {
+				end++
+			}
+			if sqlKeywordSet[strings.ToUpper(text[i:end])] {
+				mark(i, end, tokenKeyword)
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+
+	if errOffset >= 0 && errOffset < len(text) {
+		start, end := errOffset, errOffset+1
+		if isIdentByte(text[errOffset]) {
+			for start > 0 && isIdentByte(text[start-1]) {
+				start--
+			}
+			for end < len(text) && isIdentByte(text[end]) {
+				end++
+			}
+		}
+		mark(start, end, tokenError)
+	}
+
+	return kinds
+}
+
+var sqlKeywordSet = func() map[string]bool {
+	set := make(map[string]bool, len(sqlKeywords))
+	for _, kw := range sqlKeywords {
+		set[kw] = true
+	}
+	return set
+}()
+
+func isIdentByte(b byte) bool {
+	return isWordByte(b) && b != '.'
+}
+
+// editorView renders the query editor with syntax highlighting. The textarea
+// still owns editing and the cursor; this only replaces how it is drawn.
+func (m Model) editorView() string {
+	text := m.input.Value()
+	if text == "" {
+		return m.input.View()
+	}
+
+	errOffset := -1
+	if m.queryErr != nil && m.queryErr.query == text {
+		errOffset = m.queryErr.offset
+	}
+	kinds := classifySQL(text, errOffset)
+
+	_, cursor := m.inputOffset()
+	width := m.input.Width()
+	if width < 1 {
+		width = 1
+	}
+
+	// Wrap each logical line into display rows of at most width runes
+	type displayRow struct {
+		lineNumber int // 0 for continuation rows
+		start, end int // byte range in text
+	}
+	var rows []displayRow
+	cursorRow := 0
+	offset := 0
+	for n, line := range strings.Split(text, "\n") {
+		start := offset
+		first := true
+		runes := 0
+		for i := range line {
+			if runes == width {
+				rows = append(rows, displayRow{lineNumber: lineNumberIf(first, n+1), start: start, end: offset + i})
+				start, first, runes = offset+i, false, 0
+			}
+			runes++
+		}
+		rows = append(rows, displayRow{lineNumber: lineNumberIf(first, n+1), start: start, end: offset + len(line)})
+		offset += len(line) + 1
+	}
+
+	// At a wrap point the cursor belongs to the continuation row
+	for i, row := range rows {
+		if cursor >= row.start && cursor <= row.end {
+			cursorRow = i
+		}
+	}
+
+	// Scroll so the cursor row stays visible
+	height := m.input.Height()
+	first := 0
+	if cursorRow >= height {
+		first = cursorRow - height + 1
+	}
+
+	var b strings.Builder
+	for i := first; i < first+height; i++ {
+		b.WriteString(editorPrompt)
+		if i >= len(rows) {
+			b.WriteString("\n")
+			continue
+		}
+		row := rows[i]
+		number := ""
+		if row.lineNumber > 0 {
+			number = strconv.Itoa(row.lineNumber)
+		}
+		b.WriteString(lineNumberStyle.Render(fmt.Sprintf(" %*s ", lineNumberWidth-2, number)))
+
+		for j := row.start; j < row.end; {
+			r := []rune(text[j:row.end])[0]
+			size := len(string(r))
+			if j == cursor {
+				b.WriteString(cursorStyle.Render(string(r)))
+				j += size
+				continue
+			}
+			k := j + size
+			for k < row.end && kinds[k] == kinds[j] && k != cursor {
+				k++
+			}
+			b.WriteString(tokenStyles[kinds[j]].Render(text[j:k]))
+			j = k
+		}
+		if cursor == row.end && i == cursorRow {
+			b.WriteString(cursorStyle.Render(" "))
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func lineNumberIf(first bool, n int) int {
+	if first {
+		return n
+	}
+	return 0
+}
+
+// queryError is a failed query, with the byte offset DuckDB reported for
+// the error (or -1 when it gave none).
+type queryError struct {
+	query   string
+	message string
+	offset  int
+}
+
+// errorLinePattern matches DuckDB's error context line, e.g.
+// "LINE 2: FORM fields", which is followed by a line with a caret.
+var errorLinePattern = regexp.MustCompile(`^LINE (\d+): (.*)$`)
+
+// newQueryError extracts the message and position from a DuckDB error.
+// DuckDB elides long lines with "...", so the caret is mapped back onto the
+// query by locating the shown snippet in the reported line.
+func newQueryError(query string, err error) *queryError {
+	qe := &queryError{query: query, message: err.Error(), offset: -1}
+
+	lines := strings.Split(err.Error(), "\n")
+	for i, line := range lines {
+		match := errorLinePattern.FindStringSubmatch(line)
+		if match == nil || i+1 >= len(lines) {
+			continue
+		}
+		qe.message = strings.Join(strings.Fields(strings.Join(lines[:i], " ")), " ")
+
+		caret := strings.Index(lines[i+1], "^")
+		lineNo, _ := strconv.Atoi(match[1])
+		queryLines := strings.Split(query, "\n")
+		if caret < 0 || lineNo < 1 || lineNo > len(queryLines) {
+			break
+		}
+
+		snippet := match[2]
+		col := caret - (len(line) - len(snippet))
+		if strings.HasPrefix(snippet, "...") {
+			snippet = snippet[3:]
+			col -= 3
+		}
+		snippet = strings.TrimSuffix(snippet, "...")
+
+		queryLine := queryLines[lineNo-1]
+		start := strings.Index(queryLine, snippet)
+		if start < 0 || col < 0 || start+col > len(queryLine) {
+			break
+		}
+
+		offset := 0
+		for _, l := range queryLines[:lineNo-1] {
+			offset += len(l) + 1
+		}
+		qe.offset = offset + start + col
+		break
+	}
+
+	return qe
+}
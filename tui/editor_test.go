@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+func TestQueryErrorPosition(t *testing.T) {
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	long := "SELECT " + strings.Repeat("name, ", 40) + "nme FROM messages"
+	for _, tc := range []struct {
+		query string
+		token string
+	}{
+		{"SELECT nme FROM messages", "nme"},
+		{"SELECT name\nFROM messages\nWHERE nme = 'x'", "nme"},
+		{"SELECT * FROM mesages", "mesages"},
+		{long, "nme"},
+	} {
+		_, err := db.Exec(tc.query)
+		if err == nil {
+			t.Fatalf("Expected %q to fail", tc.query)
+		}
+		qe := newQueryError(tc.query, err)
+		if want := strings.LastIndex(tc.query, tc.token); qe.offset != want {
+			t.Errorf("Expected the error in %q at %d, got %d (%v)", tc.query, want, qe.offset, err)
+		}
+		if strings.Contains(qe.message, "LINE") || strings.Contains(qe.message, "\n") {
+			t.Errorf("Expected the message without the snippet, got %q", qe.message)
+		}
+	}
+}
+
+func TestQueryErrorWithoutPosition(t *testing.T) {
+	qe := newQueryError("SELECT 1", errors.New("something failed"))
+	if qe.offset != -1 || qe.message != "something failed" {
+		t.Errorf("Unexpected query error %+v", qe)
+	}
+}
@@ -0,0 +1,348 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/connor15mcc/pbql-go/output"
+)
+
+const (
+	// MaxColumnWidth caps how wide a grid column grows to fit its content.
+	MaxColumnWidth = 40
+	// ColumnGap is the space between grid columns.
+	ColumnGap = 2
+)
+
+var (
+	gridHeaderStyle       = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FF00"))
+	gridSortedHeaderStyle = gridHeaderStyle.Underline(true)
+	gridCursorRowStyle    = lipgloss.NewStyle().Background(lipgloss.Color("#303030"))
+	gridCursorCellStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#005F87")).Foreground(lipgloss.Color("#FFFFFF"))
+	detailBorderStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).PaddingLeft(1)
+	detailNameStyle       = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#5FAFFF"))
+)
+
+// grid is a navigable result set: a row and column cursor, horizontal
+// scrolling across columns sized to their content, sorting by column and an
+// optional pane showing every column of the selected row.
+type grid struct {
+	columns []string
+	values  [][]any
+	cells   [][]string
+	widths  []int
+
+	cursorRow, cursorCol int
+	offsetRow, offsetCol int
+
+	sortCol  int // -1 when unsorted
+	sortDesc bool
+
+	showDetail bool
+	focused    bool
+	width      int
+	height     int
+}
+
+func newGrid(columns []string, values [][]any) *grid {
+	g := &grid{columns: columns, values: values, sortCol: -1}
+	g.cells = make([][]string, len(values))
+	g.widths = make([]int, len(columns))
+	for i, col := range columns {
+		g.widths[i] = lipgloss.Width(col)
+	}
+	for r, row := range values {
+		g.cells[r] = make([]string, len(row))
+		for c, val := range row {
+			cell := strings.ReplaceAll(output.Value(val), "\n", " ")
+			g.cells[r][c] = cell
+			g.widths[c] = max(g.widths[c], lipgloss.Width(cell))
+		}
+	}
+	for i := range g.widths {
+		g.widths[i] = min(g.widths[i], MaxColumnWidth)
+	}
+	return g
+}
+
+func (g *grid) setSize(width, height int) {
+	g.width, g.height = width, height
+	g.clamp()
+}
+
+// handleKey applies a navigation key and reports whether it was used.
+func (g *grid) handleKey(key string) bool {
+	switch key {
+	case "up", "k":
+		g.cursorRow--
+	case "down", "j":
+		g.cursorRow++
+	case "left", "h":
+		g.cursorCol--
+	case "right", "l":
+		g.cursorCol++
+	case "pgup", "ctrl+u":
+		g.cursorRow -= g.pageSize()
+	case "pgdown", "ctrl+d":
+		g.cursorRow += g.pageSize()
+	case "home", "g":
+		g.cursorRow = 0
+	case "end", "G":
+		g.cursorRow = len(g.cells) - 1
+	case "0", "^":
+		g.cursorCol = 0
+	case "$":
+		g.cursorCol = len(g.columns) - 1
+	case "s":
+		g.sortBy(g.cursorCol)
+	case "enter", "d":
+		g.showDetail = !g.showDetail
+	default:
+		return false
+	}
+	g.clamp()
+	return true
+}
+
+// sortBy sorts rows by a column, toggling to descending when the column is
+// already the ascending sort key.
+func (g *grid) sortBy(col int) {
+	if col < 0 || col >= len(g.columns) {
+		return
+	}
+	if g.sortCol == col {
+		g.sortDesc = !g.sortDesc
+	} else {
+		g.sortCol, g.sortDesc = col, false
+	}
+
+	order := make([]int, len(g.values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		less := compareValues(g.values[order[a]][col], g.values[order[b]][col], g.cells[order[a]][col], g.cells[order[b]][col])
+		if g.sortDesc {
+			return less > 0
+		}
+		return less < 0
+	})
+
+	values := make([][]any, len(order))
+	cells := make([][]string, len(order))
+	for i, idx := range order {
+		values[i], cells[i] = g.values[idx], g.cells[idx]
+	}
+	g.values, g.cells = values, cells
+}
+
+// compareValues orders numbers numerically, NULLs first and everything else
+// by its displayed text.
+func compareValues(a, b any, aText, bText string) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1
+			case af > bf:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(aText, bText)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func (g *grid) pageSize() int {
+	return max(1, g.height-2)
+}
+
+// clamp keeps the cursor in range and scrolls so it stays visible.
+func (g *grid) clamp() {
+	g.cursorRow = max(0, min(g.cursorRow, len(g.cells)-1))
+	g.cursorCol = max(0, min(g.cursorCol, len(g.columns)-1))
+
+	visibleRows := g.pageSize()
+	if g.cursorRow < g.offsetRow {
+		g.offsetRow = g.cursorRow
+	}
+	if g.cursorRow >= g.offsetRow+visibleRows {
+		g.offsetRow = g.cursorRow - visibleRows + 1
+	}
+
+	if g.cursorCol < g.offsetCol {
+		g.offsetCol = g.cursorCol
+	}
+	for g.offsetCol < g.cursorCol && g.lastVisibleCol() < g.cursorCol {
+		g.offsetCol++
+	}
+}
+
+func (g *grid) gridWidth() int {
+	if g.showDetail {
+		return g.width - g.detailWidth()
+	}
+	return g.width
+}
+
+func (g *grid) detailWidth() int {
+	return g.width * 2 / 5
+}
+
+// lastVisibleCol is the last column that fits when scrolled to offsetCol.
+func (g *grid) lastVisibleCol() int {
+	used := 0
+	last := g.offsetCol
+	for c := g.offsetCol; c < len(g.columns); c++ {
+		used += g.widths[c] + ColumnGap
+		if used > g.gridWidth() && c > g.offsetCol {
+			break
+		}
+		last = c
+	}
+	return last
+}
+
+func (g *grid) View() string {
+	if len(g.columns) == 0 {
+		return "No results"
+	}
+
+	last := g.lastVisibleCol()
+	var b strings.Builder
+
+	// Header
+	for c := g.offsetCol; c <= last; c++ {
+		title := g.columns[c]
+		style := gridHeaderStyle
+		if c == g.sortCol {
+			style = gridSortedHeaderStyle
+			if g.sortDesc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+		b.WriteString(style.Render(pad(title, g.widths[c])))
+		b.WriteString(strings.Repeat(" ", ColumnGap))
+	}
+	b.WriteString("\n")
+
+	// Rows
+	for r := g.offsetRow; r < len(g.cells) && r < g.offsetRow+g.pageSize(); r++ {
+		var line strings.Builder
+		for c := g.offsetCol; c <= last; c++ {
+			cell := pad(g.cells[r][c], g.widths[c])
+			if g.focused && r == g.cursorRow && c == g.cursorCol {
+				cell = gridCursorCellStyle.Render(cell)
+			} else if r == g.cursorRow {
+				cell = gridCursorRowStyle.Render(cell)
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", ColumnGap))
+		}
+		b.WriteString(line.String())
+		b.WriteString("\n")
+	}
+
+	// Footer with position
+	footer := fmt.Sprintf("row %d/%d  col %d/%d", g.cursorRow+1, len(g.cells), g.cursorCol+1, len(g.columns))
+	if len(g.cells) == 0 {
+		footer = "(0 rows)"
+	}
+	b.WriteString(statusStyle.Render(footer))
+
+	view := lipgloss.NewStyle().MaxWidth(g.gridWidth()).Render(b.String())
+	if !g.showDetail || len(g.cells) == 0 {
+		return view
+	}
+
+	gridPane := lipgloss.NewStyle().Width(g.gridWidth()).Render(view)
+	return lipgloss.JoinHorizontal(lipgloss.Top, gridPane, g.detailView())
+}
+
+// detailView shows every column of the selected row, pretty-printing JSON
+// values such as options and features.
+func (g *grid) detailView() string {
+	var b strings.Builder
+	for c, col := range g.columns {
+		b.WriteString(detailNameStyle.Render(col))
+		b.WriteString("\n")
+		b.WriteString(detailValue(g.values[g.cursorRow][c]))
+		b.WriteString("\n")
+	}
+
+	width := g.detailWidth() - 2
+	content := lipgloss.NewStyle().Width(width).Render(strings.TrimSuffix(b.String(), "\n"))
+	lines := strings.Split(content, "\n")
+	if len(lines) > g.height {
+		lines = lines[:g.height]
+	}
+	return detailBorderStyle.Height(g.height).Render(strings.Join(lines, "\n"))
+}
+
+// detailValue renders a value for the detail pane; maps and lists (JSON
+// columns) are shown as indented JSON.
+func detailValue(val any) string {
+	switch val.(type) {
+	case map[string]any, []any:
+		if data, err := json.MarshalIndent(val, "", "  "); err == nil {
+			return string(data)
+		}
+	}
+	return output.Value(val)
+}
+
+// pad truncates or right-pads s to exactly width cells.
+func pad(s string, width int) string {
+	if lipgloss.Width(s) > width {
+		runes := []rune(s)
+		if len(runes) > width {
+			runes = runes[:width]
+		}
+		for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+			runes = runes[:len(runes)-1]
+		}
+		s = string(runes) + "…"
+	}
+	return s + strings.Repeat(" ", max(0, width-lipgloss.Width(s)))
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+)
+
+// gridColumn returns a grid column's values, as displayed.
+func gridColumn(g *grid, col int) []string {
+	var cells []string
+	for _, row := range g.cells {
+		cells = append(cells, row[col])
+	}
+	return cells
+}
+
+func TestGridSort(t *testing.T) {
+	g := newGrid([]string{"name", "number"}, [][]any{
+		{"b", int32(10)},
+		{"a", int32(9)},
+		{nil, int32(100)},
+		{"c", nil},
+	})
+
+	// Numbers sort numerically, not as text, with NULLs first
+	g.sortBy(1)
+	if got := gridColumn(g, 1); !slices.Equal(got, []string{"NULL", "9", "10", "100"}) {
+		t.Errorf("Unexpected ascending order %v", got)
+	}
+	// Rows move together
+	if got := gridColumn(g, 0); !slices.Equal(got, []string{"c", "a", "b", "NULL"}) {
+		t.Errorf("Expected rows to be sorted whole, got %v", got)
+	}
+
+	// Sorting by the same column again reverses it
+	g.sortBy(1)
+	if got := gridColumn(g, 1); !slices.Equal(got, []string{"100", "10", "9", "NULL"}) || !g.sortDesc {
+		t.Errorf("Unexpected descending order %v", got)
+	}
+
+	// Another column sorts ascending, by text
+	g.sortBy(0)
+	if got := gridColumn(g, 0); !slices.Equal(got, []string{"NULL", "a", "b", "c"}) || g.sortDesc {
+		t.Errorf("Unexpected order %v", got)
+	}
+	if g.values[1][0] != "a" {
+		t.Errorf("Expected the values to follow the cells, got %v", g.values)
+	}
+}
+
+func TestGridKeys(t *testing.T) {
+	g := newGrid([]string{"a", "b"}, [][]any{{1, 2}, {3, 4}, {5, 6}})
+	g.setSize(80, 20)
+
+	g.handleKey("G")
+	g.handleKey("$")
+	if g.cursorRow != 2 || g.cursorCol != 1 {
+		t.Errorf("Expected the cursor at the last cell, got %d,%d", g.cursorRow, g.cursorCol)
+	}
+	// The cursor stays within the grid
+	g.handleKey("down")
+	g.handleKey("right")
+	if g.cursorRow != 2 || g.cursorCol != 1 {
+		t.Errorf("Expected the cursor to stay at the last cell, got %d,%d", g.cursorRow, g.cursorCol)
+	}
+	if g.handleKey("x") {
+		t.Errorf("Expected unknown keys to be left unhandled")
+	}
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	jsonKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#5FAFFF"))
+	jsonStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#87D787"))
+	jsonNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF5F"))
+	jsonLiteralStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#D787D7"))
+)
+
+// highlightJSON colors pretty-printed JSON for display: object keys, string
+// values, numbers and true/false/null literals each get their own style.
+// Layout is preserved, so the input should already be indented.
+func highlightJSON(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(s) {
+				end++
+			}
+			token := s[i:end]
+
+			// A string directly followed by ':' is an object key
+			rest := strings.TrimLeft(s[end:], " ")
+			if strings.HasPrefix(rest, ":") {
+				b.WriteString(jsonKeyStyle.Render(token))
+			} else {
+				b.WriteString(jsonStringStyle.Render(token))
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && strings.IndexByte("0123456789.eE+-", s[end]) >= 0 {
+				end++
+			}
+			b.WriteString(jsonNumberStyle.Render(s[i:end]))
+			i = end
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "null"):
+			b.WriteString(jsonLiteralStyle.Render(s[i : i+4]))
+			i += 4
+		case strings.HasPrefix(s[i:], "false"):
+			b.WriteString(jsonLiteralStyle.Render(s[i : i+5]))
+			i += 5
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
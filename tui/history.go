@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultHistorySize is the number of distinct queries kept in the history
+// file when no size is configured.
+const DefaultHistorySize = 1000
+
+// historyEntry is a query and the time it was last run.
+type historyEntry struct {
+	query string
+	time  time.Time
+}
+
+// history is the list of previously run queries, newest first, backed by an
+// append-only file that is compacted once it holds twice size records, so
+// that most queries only append to it.
+type history struct {
+	path    string
+	size    int
+	entries []historyEntry
+	// records is the number of entries in the file, including duplicates
+	// that have not been compacted away yet
+	records int
+}
+
+// HistoryPath returns the history file for a project, keyed by its input
+// roots (the proto files and directories given on the command line), so
+// that each project keeps its own history.
+func HistoryPath(roots []string) (string, error) {
+	return projectPath("history", roots)
+}
+
+// projectPath returns the path of a project's file under ~/.pbql/<kind>,
+// named after the project's first root and a hash of all of them.
+func projectPath(kind string, roots []string) (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	abs := make([]string, len(roots))
+	for i, root := range roots {
+		if abs[i], err = filepath.Abs(root); err != nil {
+			return "", err
+		}
+	}
+	slices.Sort(abs)
+	abs = slices.Compact(abs)
+
+	sum := sha256.Sum256([]byte(strings.Join(abs, "\n")))
+	name := "default"
+	if len(abs) > 0 {
+		name = filepath.Base(abs[0])
+	}
+	file := fmt.Sprintf("%s-%s", name, hex.EncodeToString(sum[:])[:12])
+	return filepath.Join(usr.HomeDir, ".pbql", kind, file), nil
+}
+
+// LegacyHistoryPath returns the history file shared by all projects before
+// each got its own, which is imported into a project's history on first use.
+func LegacyHistoryPath() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, ".pbql_history"), nil
+}
+
+// loadHistory reads the history file at path. If it does not exist yet, the
+// legacy history file is imported instead, if given. A missing file is an
+// empty history; a size of zero or less keeps every query.
+func loadHistory(path, legacy string, size int) *history {
+	h := &history{path: path, size: size}
+	if path == "" {
+		return h
+	}
+
+	entries, err := readHistory(path)
+	if errors.Is(err, fs.ErrNotExist) && legacy != "" {
+		if entries, err = readHistory(legacy); err == nil && len(entries) > 0 {
+			h.dedup(entries)
+			if err := h.compact(); err != nil {
+				slog.Info("failed to import the legacy history", "error", err)
+			}
+			return h
+		}
+	}
+	if err != nil {
+		return h
+	}
+
+	h.records = len(entries)
+	h.dedup(entries)
+	return h
+}
+
+// readHistory reads the records of a history file, oldest first.
+func readHistory(path string) ([]historyEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Records are a "# <timestamp>" line followed by the query and a blank
+	// line, oldest first. Blank lines within a query are kept.
+	var entries []historyEntry
+	var current []string
+	var ts time.Time
+	flush := func() {
+		query := strings.TrimSpace(strings.Join(current, "\n"))
+		if query != "" {
+			entries = append(entries, historyEntry{query: query, time: ts})
+		}
+		current = nil
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "# "); ok {
+			if t, err := time.Parse(time.RFC3339, header); err == nil {
+				flush()
+				ts = t
+				continue
+			}
+		}
+		current = append(current, line)
+	}
+	flush()
+	return entries, scanner.Err()
+}
+
+// dedup sets the history to records read oldest first, keeping the most
+// recent copy of each query.
+func (h *history) dedup(entries []historyEntry) {
+	seen := make(map[string]struct{}, len(entries))
+	for _, e := range slices.Backward(entries) {
+		if _, ok := seen[e.query]; !ok {
+			seen[e.query] = struct{}{}
+			h.entries = append(h.entries, e)
+		}
+	}
+	h.trim()
+}
+
+func (h *history) trim() {
+	if h.size > 0 && len(h.entries) > h.size {
+		h.entries = h.entries[:h.size]
+	}
+}
+
+func (h *history) len() int {
+	return len(h.entries)
+}
+
+// at returns the i-th most recent query.
+func (h *history) at(i int) string {
+	return h.entries[i].query
+}
+
+// add records a query as the most recent, dropping earlier copies of it,
+// and appends it to the history file.
+func (h *history) add(query string) error {
+	entry := historyEntry{query: query, time: time.Now()}
+	h.entries = slices.DeleteFunc(h.entries, func(e historyEntry) bool { return e.query == query })
+	h.entries = slices.Insert(h.entries, 0, entry)
+	h.trim()
+
+	if h.path == "" {
+		return nil
+	}
+	if h.size > 0 && h.records >= 2*h.size {
+		return h.compact()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(formatHistoryEntry(entry)); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	h.records++
+	return nil
+}
+
+// compact rewrites the history file with only the retained, de-duplicated
+// entries.
+func (h *history) compact() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	var b strings.Builder
+	for _, e := range slices.Backward(h.entries) {
+		b.WriteString(formatHistoryEntry(e))
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("failed to replace history file: %w", err)
+	}
+	h.records = len(h.entries)
+	return nil
+}
+
+func formatHistoryEntry(e historyEntry) string {
+	return fmt.Sprintf("# %s\n%s\n\n", e.time.Format(time.RFC3339), e.query)
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryDeduplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := loadHistory(path, "", 10)
+	for _, q := range []string{"SELECT 1", "SELECT 2", "SELECT 1"} {
+		if err := h.add(q); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if h.len() != 2 || h.at(0) != "SELECT 1" || h.at(1) != "SELECT 2" {
+		t.Errorf("Unexpected history %v", h.entries)
+	}
+
+	// The file keeps every record until it is compacted, but loading it
+	// de-duplicates them
+	h = loadHistory(path, "", 10)
+	if h.records != 3 || h.len() != 2 || h.at(0) != "SELECT 1" {
+		t.Errorf("Unexpected loaded history %v (%d records)", h.entries, h.records)
+	}
+}
+
+func TestHistoryTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := loadHistory(path, "", 2)
+	for _, q := range []string{"SELECT 1", "SELECT 2", "SELECT 3"} {
+		h.add(q)
+	}
+	if h.len() != 2 || h.at(0) != "SELECT 3" || h.at(1) != "SELECT 2" {
+		t.Errorf("Unexpected history %v", h.entries)
+	}
+	if h = loadHistory(path, "", 2); h.len() != 2 || h.at(1) != "SELECT 2" {
+		t.Errorf("Unexpected loaded history %v", h.entries)
+	}
+}
+
+func TestHistoryCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := loadHistory(path, "", 3)
+
+	// Queries are appended until the file holds twice the size in records,
+	// then it is rewritten with the retained queries only
+	for i, q := range []string{"a", "b", "c", "d", "e"} {
+		h.add(q)
+		if h.records != i+1 {
+			t.Fatalf("Expected %d records after %q, got %d", i+1, q, h.records)
+		}
+	}
+	h.add("f")
+	if h.records != 6 {
+		t.Fatalf("Expected 6 records, got %d", h.records)
+	}
+	h.add("g")
+	if h.records != 3 {
+		t.Fatalf("Expected the file to be compacted to 3 records, got %d", h.records)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := strings.Count(string(data), "# "); n != 3 {
+		t.Errorf("Expected 3 records in the file, got %d:\n%s", n, data)
+	}
+
+	// After compaction, queries are appended again
+	h.add("h")
+	if h.records != 4 {
+		t.Errorf("Expected 4 records, got %d", h.records)
+	}
+	if h = loadHistory(path, "", 3); h.at(0) != "h" || h.at(2) != "f" {
+		t.Errorf("Unexpected loaded history %v", h.entries)
+	}
+}
+
+func TestHistoryMultilineQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := loadHistory(path, "", 10)
+	query := "SELECT *\n\nFROM messages"
+	h.add(query)
+	if h = loadHistory(path, "", 10); h.len() != 1 || h.at(0) != query {
+		t.Errorf("Unexpected loaded history %v", h.entries)
+	}
+}
+
+func TestHistoryImportsLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, ".pbql_history")
+	os.WriteFile(legacy, []byte("# 2024-01-01T00:00:00Z\nSELECT 1\n\n# 2024-01-02T00:00:00Z\nSELECT 2\n\n# 2024-01-03T00:00:00Z\nSELECT 1\n\n"), 0644)
+
+	path := filepath.Join(dir, "history", "project")
+	h := loadHistory(path, legacy, 10)
+	if h.len() != 2 || h.at(0) != "SELECT 1" || h.at(1) != "SELECT 2" {
+		t.Fatalf("Unexpected imported history %v", h.entries)
+	}
+
+	// The import is written to the project's file, which is used from then
+	// on
+	os.Remove(legacy)
+	h.add("SELECT 3")
+	if h = loadHistory(path, legacy, 10); h.len() != 3 || h.at(2) != "SELECT 2" {
+		t.Errorf("Unexpected history %v", h.entries)
+	}
+}
@@ -3,17 +3,20 @@ package tui
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"os"
-	"os/user"
-	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/connor15mcc/pbql-go/output"
+	"github.com/connor15mcc/pbql-go/schema"
 )
 
 const (
@@ -24,25 +27,126 @@ const (
 	DefaultTerminalWidth  = 80
 )
 
+// focus is the pane receiving key presses.
+type focus int
+
+const (
+	focusEditor focus = iota
+	focusResults
+	focusBrowser
+	focusQueries
+)
+
 type Model struct {
-	db         *sql.DB
-	format     string
-	input      textarea.Model
-	results    viewport.Model
-	width      int
-	height     int
-	history    []string
-	historyPos int
+	db     *sql.DB
+	schema *schema.DB
+	// roots are the proto inputs given on the command line and loaded the
+	// paths added with .load; both are parsed again by .reload
+	roots  []string
+	loaded []string
+	format string
+	// output receives query results instead of the results pane, when set
+	// with .output
+	output *os.File
+	// terminal is the program's output, for escape sequences such as the
+	// clipboard's
+	terminal io.Writer
+	// timer shows each query's run time in the status line
+	timer bool
+	// tabs are the open editors; the fields from input to historyPos hold
+	// the state of the active one
+	tabs      []tab
+	activeTab int
+	// tabsFile records the open tabs on exit, and queriesDir holds the
+	// saved queries listed in the queries panel
+	tabsFile   string
+	queriesDir string
+	queries    *queryPanel
+	// showQueries toggles the saved queries sidebar
+	showQueries bool
+	input       textarea.Model
+	results     viewport.Model
+	grid        *grid
+	// preview shows the source of the selected row below the grid, while
+	// open
+	preview *sourcePreview
+	// resultCols and resultValues are the last result set, copied and
+	// exported in clipboardFormat and by .export
+	resultCols      []string
+	resultValues    [][]any
+	clipboardFormat string
+	focus           focus
+	browser         *browser
+	// showBrowser toggles the schema browser sidebar
+	showBrowser bool
+	width       int
+	height      int
+	history     *history
+	historyPos  int
+	// search is the Ctrl+R history search overlay, while open
+	search *historySearch
+	// skipFailed keeps failed queries out of the history
+	skipFailed bool
+	completer  *completer
+	// status is a one-line message shown between the results and the
+	// editor, e.g. completion candidates. It is cleared on the next key.
+	status string
+	// queryErr is the last failed query; its message is shown in the status
+	// line and its position highlighted until the query is edited.
+	queryErr *queryError
+	// running is the query currently executing, if any
+	running *runningQuery
+	queryID int
+	spinner spinner.Model
+}
+
+var statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+
+// Options configures the interactive mode.
+type Options struct {
+	Format string
+	// Roots are the proto files and directories the database was loaded
+	// from, parsed again by .reload.
+	Roots []string
+	// HistoryFile is where queries are recorded; empty disables
+	// persisting history.
+	HistoryFile string
+	// LegacyHistoryFile is imported into HistoryFile when that does not
+	// exist yet; empty skips the import.
+	LegacyHistoryFile string
+	// HistorySize is the number of distinct queries kept in the history
+	// file; zero or less keeps every query.
+	HistorySize int
+	// HistorySkipFailed keeps queries that failed out of the history.
+	HistorySkipFailed bool
+	// TabsFile records the open tabs between sessions; empty disables
+	// persisting them.
+	TabsFile string
+	// QueriesDir is the directory of saved queries.
+	QueriesDir string
 }
 
-func Run(db *sql.DB, format string) error {
-	m := initialModel(db, format)
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	_, err := p.Run()
+func Run(db *schema.DB, opts Options) error {
+	m := initialModel(db, opts)
+	terminal := &terminalWriter{File: os.Stdout}
+	m.terminal = terminal
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(terminal))
+	final, err := p.Run()
+	m, ok := final.(Model)
+	if !ok {
+		return err
+	}
+	if m.output != nil {
+		m.output.Close()
+	}
+	if saveErr := m.saveTabs(); err == nil && saveErr != nil {
+		err = fmt.Errorf("failed to save tabs: %w", saveErr)
+	}
 	return err
 }
 
-func initialModel(db *sql.DB, format string) Model {
+// newEditor returns the textarea queries are typed in.
+func newEditor() textarea.Model {
 	ta := textarea.New()
 	ta.Placeholder = "Enter SQL query... (press Enter to execute, Ctrl+C to quit)"
 	ta.Focus()
@@ -51,14 +155,19 @@ func initialModel(db *sql.DB, format string) Model {
 	ta.SetHeight(InitialTextareaHeight)
 	ta.FocusedStyle = textarea.Style{Base: lipgloss.NewStyle()}
 	ta.BlurredStyle = textarea.Style{Base: lipgloss.NewStyle()}
+	return ta
+}
 
-	history := loadHistory()
-
+func initialModel(db *schema.DB, opts Options) Model {
 	welcomeRows := []table.Row{
 		{"Welcome to pbql-go"},
 		{""},
 		{"Type a SQL query and press Enter"},
 		{"Ctrl+J - Insert newline"},
+		{"Up/Down - Previous queries, Ctrl+R - Search history"},
+		{"Shift+Tab - Browse results (arrows, s to sort, Enter for details)"},
+		{"Ctrl+B - Browse the schema"},
+		{"Ctrl+T - New tab, Ctrl+O - Saved queries"},
 		{"Ctrl+C or .quit - Exit"},
 		{".help, .tables, .schema - More commands"},
 	}
@@ -70,19 +179,27 @@ func initialModel(db *sql.DB, format string) Model {
 		table.WithFocused(false),
 	)
 
-	vp := viewport.New(DefaultTerminalWidth, 0)
-	vp.SetContent(t.View())
-
-	return Model{
-		db:         db,
-		format:     format,
-		input:      ta,
-		results:    vp,
-		width:      80,
-		height:     24,
-		history:    history,
-		historyPos: -1,
+	tabs, active := loadTabs(opts.TabsFile, opts.QueriesDir)
+	tabs[active].results.SetContent(t.View())
+
+	m := Model{
+		db:              db.DB,
+		schema:          db,
+		roots:           opts.Roots,
+		format:          opts.Format,
+		tabs:            tabs,
+		tabsFile:        opts.TabsFile,
+		queriesDir:      opts.QueriesDir,
+		width:           80,
+		height:          24,
+		history:         loadHistory(opts.HistoryFile, opts.LegacyHistoryFile, opts.HistorySize),
+		skipFailed:      opts.HistorySkipFailed,
+		completer:       newCompleter(db.DB),
+		clipboardFormat: output.TSV,
+		spinner:         spinner.New(spinner.WithSpinner(spinner.Dot)),
 	}
+	m.restoreTab(active)
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
@@ -94,20 +211,87 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case queryResultMsg:
+		return m, m.handleQueryResult(msg)
+	case clipboardMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Copy failed: %v", msg.err)
+		}
+		return m, nil
+	case completerMsg:
+		m.completer = msg.completer
+		return m, nil
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Editor failed: %v", msg.err)
+		}
+		// The file may have been edited
+		if m.preview != nil {
+			m.preview = m.loadPreview(nil)
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if m.running == nil {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case tea.KeyMsg:
+		m.status = ""
+		if m.running != nil && (msg.String() == "ctrl+c" || msg.String() == "esc") {
+			m.cancelQuery()
+			return m, nil
+		}
+		if m.search != nil {
+			return m.updateSearch(msg)
+		}
+		if m.handleTabKey(msg.String()) {
+			return m, nil
+		}
 		switch msg.String() {
+		case "ctrl+b":
+			m.toggleBrowser()
+			m.recalculateLayout()
+			return m, nil
+		case "ctrl+o":
+			m.toggleQueries()
+			m.recalculateLayout()
+			return m, nil
+		}
+		switch m.focus {
+		case focusResults:
+			return m.updateResults(msg)
+		case focusBrowser:
+			return m.updateBrowser(msg)
+		case focusQueries:
+			return m.updateQueries(msg)
+		}
+		switch msg.String() {
+		case "shift+tab":
+			if m.grid != nil {
+				m.setFocus(focusResults)
+			}
+			return m, nil
+		case "tab":
+			m.completeInput()
+			m.recalculateLayout()
+			return m, nil
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "ctrl+r":
+			m.search = newHistorySearch(m.history)
+			m.recalculateLayout()
+			return m, nil
 		case "up", "ctrl+p":
-			if m.historyPos < len(m.history)-1 {
+			if m.historyPos < m.history.len()-1 {
 				m.historyPos++
-				m.input.SetValue(m.history[m.historyPos])
+				m.input.SetValue(m.history.at(m.historyPos))
 			}
 			return m, nil
 		case "down", "ctrl+n":
 			if m.historyPos > 0 {
 				m.historyPos--
-				m.input.SetValue(m.history[m.historyPos])
+				m.input.SetValue(m.history.at(m.historyPos))
 			} else if m.historyPos == 0 {
 				m.historyPos = -1
 				m.input.SetValue("")
@@ -127,14 +311,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if strings.HasPrefix(query, ".") {
 				return m.handleCommand(query)
 			}
-			results, cols := executeQuery(m.db, query)
-			m.results.SetContent(buildTable(results, cols, m.width))
-			appendToHistory(query)
-
-			m.history = loadHistory()
+			if m.running != nil {
+				m.status = "A query is already running (Esc to cancel)"
+				return m, nil
+			}
 			m.historyPos = -1
-			m.input.Reset()
-			return m, nil
+			return m, m.startQuery(query, true, true)
 		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -144,6 +326,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.input, cmd = m.input.Update(msg)
 	cmds = append(cmds, cmd)
 
+	if m.queryErr != nil && m.queryErr.query != m.input.Value() {
+		m.queryErr = nil
+	}
+
 	// Recalculate heights
 	m.recalculateLayout()
 	return m, tea.Batch(cmds...)
@@ -160,81 +346,433 @@ func (m *Model) recalculateLayout() {
 		textareaHeight = InitialTextareaHeight
 	}
 	m.input.SetHeight(textareaHeight)
-	m.input.SetWidth(m.width)
+	m.input.SetWidth(m.contentWidth())
 
 	// Calculate table height
 	tableHeight := m.height - textareaHeight - LayoutGap
+	if line, _ := m.statusLine(); line != "" {
+		tableHeight--
+	}
+	if m.tabBar() != "" {
+		tableHeight--
+	}
 	if tableHeight < MinTableHeight {
 		tableHeight = MinTableHeight
 	}
 	m.results.Height = tableHeight
-	m.results.Width = m.width
+	m.results.Width = m.contentWidth()
+	if m.grid != nil {
+		gridHeight := tableHeight
+		if m.preview != nil {
+			gridHeight -= tableHeight / 2
+		}
+		m.grid.setSize(m.contentWidth(), gridHeight)
+	}
+	if m.browser != nil {
+		m.browser.height = m.height
+		m.browser.clamp()
+	}
+	if m.queries != nil {
+		m.queries.height = m.height
+		m.queries.clamp()
+	}
+	if m.search != nil {
+		m.search.setSize(m.contentWidth(), tableHeight)
+	}
+}
+
+// contentWidth is the width left for results and the editor.
+func (m Model) contentWidth() int {
+	if m.showBrowser || m.showQueries {
+		return max(1, m.width-BrowserWidth-1)
+	}
+	return m.width
+}
+
+// toggleBrowser opens and focuses the schema browser, focuses it if it is
+// open but unfocused, or closes it.
+func (m *Model) toggleBrowser() {
+	switch {
+	case !m.showBrowser:
+		m.showQueries = false
+		if m.browser == nil {
+			b, err := newBrowser(m.db)
+			if err != nil {
+				m.status = fmt.Sprintf("Schema browser unavailable: %v", err)
+				return
+			}
+			m.browser = b
+		}
+		m.showBrowser = true
+		m.setFocus(focusBrowser)
+	case m.focus != focusBrowser:
+		m.setFocus(focusBrowser)
+	default:
+		m.showBrowser = false
+		m.setFocus(focusEditor)
+	}
+}
+
+// updateBrowser handles keys while the schema browser has focus. Enter shows
+// the selected element's row; i inserts a query over its children into the
+// editor.
+func (m Model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	node := m.browser.selected()
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "tab", "shift+tab":
+		m.setFocus(focusEditor)
+		return m, nil
+	case "enter":
+		if node == nil || m.running != nil {
+			return m, nil
+		}
+		cmd := m.startQuery(node.detailQuery(), false, false)
+		m.running.doneStatus = fmt.Sprintf("Press i to insert: %s", node.templateQuery())
+		return m, cmd
+	case "i":
+		if node != nil {
+			m.input.SetValue(node.templateQuery())
+			m.setFocus(focusEditor)
+			m.recalculateLayout()
+		}
+		return m, nil
+	}
+	m.browser.handleKey(msg.String())
+	return m, nil
+}
+
+// updateSearch handles keys while the history search is open. Enter puts
+// the selected query in the editor; Esc leaves the editor unchanged.
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "tab":
+		if query, ok := m.search.selected(); ok {
+			m.input.SetValue(query)
+			m.historyPos = -1
+		}
+		m.search = nil
+	case "esc", "ctrl+c", "ctrl+g":
+		m.search = nil
+	default:
+		var runes []rune
+		if (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) && !msg.Alt {
+			runes = msg.Runes
+		}
+		m.search.handleKey(msg.String(), runes)
+	}
+	m.recalculateLayout()
+	return m, nil
+}
+
+// updateResults handles keys while the results grid has focus. e opens the
+// selected row's proto source in the editor and p toggles its preview; y, Y
+// and a copy the selected cell, the selected row and all rows.
+func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "shift+tab", "tab":
+		m.setFocus(focusEditor)
+		return m, nil
+	case "e":
+		loc, err := m.locateRow()
+		if err != nil {
+			m.status = fmt.Sprintf("Cannot open source: %v", err)
+			return m, nil
+		}
+		return m, openEditor(loc)
+	case "y":
+		return m, m.copyCell()
+	case "Y":
+		return m, m.copyRows(true)
+	case "a":
+		return m, m.copyRows(false)
+	case "p":
+		if m.preview == nil {
+			m.preview = m.loadPreview(nil)
+		} else {
+			m.preview = nil
+		}
+		m.recalculateLayout()
+		return m, nil
+	}
+	if m.grid.handleKey(msg.String()) {
+		m.refreshPreview()
+	}
+	return m, nil
+}
+
+func (m *Model) setFocus(f focus) {
+	m.focus = f
+	if m.grid != nil {
+		m.grid.focused = f == focusResults
+	}
+	if m.browser != nil {
+		m.browser.focused = f == focusBrowser
+	}
+	if m.queries != nil {
+		m.queries.focused = f == focusQueries
+	}
+	if f == focusEditor {
+		m.input.Focus()
+	} else {
+		m.input.Blur()
+	}
+}
+
+// setResults replaces the results pane with static content.
+func (m *Model) setResults(content string) {
+	m.grid = nil
+	m.setFocus(focusEditor)
+	m.results.SetContent(content)
+}
+
+// resultsView renders the grid or the static results, padded to the height
+// of the results pane so the editor stays in place.
+func (m Model) resultsView() string {
+	if m.search != nil {
+		return m.search.View()
+	}
+	if m.grid == nil {
+		return m.results.View()
+	}
+	view := m.grid.View()
+	if lines := strings.Count(view, "\n") + 1; lines < m.grid.height {
+		view += strings.Repeat("\n", m.grid.height-lines)
+	}
+	if m.preview != nil {
+		view += "\n" + m.preview.View(m.contentWidth(), m.results.Height-m.grid.height)
+	}
+	return view
 }
 
 func (m Model) View() string {
 	m.recalculateLayout()
-	return m.results.View() + strings.Repeat("\n", LayoutGap) + m.input.View()
+	view := m.resultsView() + strings.Repeat("\n", LayoutGap)
+	if bar := m.tabBar(); bar != "" {
+		view = bar + "\n" + view
+	}
+	if line, style := m.statusLine(); line != "" {
+		view += style.MaxWidth(m.contentWidth()).Render(line) + "\n"
+	}
+	view += m.editorView()
+
+	switch {
+	case m.showBrowser && m.browser != nil:
+		return lipgloss.JoinHorizontal(lipgloss.Top, m.browser.View(), view)
+	case m.showQueries && m.queries != nil:
+		return lipgloss.JoinHorizontal(lipgloss.Top, m.queries.View(), view)
+	}
+	return view
+}
+
+// statusLine returns the text and style of the line between the results and
+// the editor: a running query's progress, a transient status message, or the
+// last query error.
+func (m Model) statusLine() (string, lipgloss.Style) {
+	switch {
+	case m.running != nil:
+		elapsed := time.Since(m.running.started).Truncate(100 * time.Millisecond)
+		return fmt.Sprintf("%s %s… %s (Esc or Ctrl+C to cancel)", m.spinner.View(), m.running.label, elapsed), statusStyle
+	case m.status != "":
+		return m.status, statusStyle
+	case m.queryErr != nil:
+		return m.queryErr.message, errorStatusStyle
+	}
+	return "", statusStyle
+}
+
+// completeInput completes the word before the cursor. A single candidate
+// replaces the whole word, including any part of it after the cursor; with
+// several, their common prefix replaces the part before the cursor and the
+// candidates are listed in the status line. Either way the word takes the
+// candidates' case.
+func (m *Model) completeInput() {
+	text, offset := m.inputOffset()
+	word, candidates := m.completer.complete(text, offset)
+
+	switch len(candidates) {
+	case 0:
+		m.status = "No completions"
+	case 1:
+		rest := 0
+		for offset+rest < len(text) && isWordByte(text[offset+rest]) {
+			rest++
+		}
+		m.replaceAtCursor(utf8.RuneCountInString(word), rest, candidates[0])
+	default:
+		if prefix := commonPrefix(candidates); len(prefix) > len(word) {
+			m.replaceAtCursor(utf8.RuneCountInString(word), 0, prefix)
+		}
+		m.status = strings.Join(candidates, "  ")
+	}
+}
+
+// replaceAtCursor deletes before characters before the cursor and after
+// characters after it, and inserts s in their place.
+func (m *Model) replaceAtCursor(before, after int, s string) {
+	for range before {
+		m.input, _ = m.input.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	for range after {
+		m.input, _ = m.input.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	}
+	m.input.InsertString(s)
+}
+
+// inputOffset returns the editor contents and the byte offset of the cursor.
+func (m Model) inputOffset() (string, int) {
+	text := m.input.Value()
+	lines := strings.Split(text, "\n")
+	row := m.input.Line()
+	if row >= len(lines) {
+		return text, len(text)
+	}
+
+	offset := 0
+	for _, line := range lines[:row] {
+		offset += len(line) + 1
+	}
+
+	info := m.input.LineInfo()
+	col := info.StartColumn + info.CharOffset
+	runes := []rune(lines[row])
+	if col > len(runes) {
+		col = len(runes)
+	}
+	return text, offset + len(string(runes[:col]))
 }
 
 func (m Model) handleCommand(cmd string) (Model, tea.Cmd) {
-	switch strings.ToLower(cmd) {
+	name, arg, _ := strings.Cut(cmd, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch strings.ToLower(name) {
 	case ".quit", ".exit", ".q":
 		return m, tea.Quit
 	case ".help", ".h", ".?":
 		rows := []table.Row{
 			{".help, .h, .?", "Show this help"},
-			{".tables", "List all tables"},
-			{".schema", "Show detailed schema"},
-			{".format <fmt>", "Set output format"},
+			{".tables", "List tables, views and macros"},
+			{".schema", "Show every column with its type and description"},
+			{".describe <name>", "Show a table's columns or a proto element's definition"},
+			{".format, .mode [fmt]", "Show or set output format (table, json, csv)"},
+			{".read <file>", "Run the ;-separated statements in a file"},
+			{".load <path...>", "Parse proto files or directories and add them"},
+			{".reload", "Re-parse all inputs"},
+			{".output [file]", "Write results to a file; without a file, show them again"},
+			{".timer on|off", "Show how long each query takes"},
+			{".export <file>", "Write the last results to a file, formatted by extension (.csv, .tsv, .json, .md, .txt)"},
+			{".clipboard [fmt]", "Show or set the format rows are copied in (tsv, json, markdown)"},
+			{".save [name]", "Save the tab's last query to " + QueriesDir + "/<name>.sql and keep it in the tab"},
+			{".open <name>", "Open a saved query in a tab"},
+			{".close", "Close the tab"},
 			{".quit, .exit", "Exit interactive mode"},
 			{"Enter", "Execute query"},
+			{"Shift+Tab", "Focus results: arrows/hjkl move, s sorts, Enter shows row details, Esc returns"},
+			{"e, p (results)", "Open the selected row's proto source in $EDITOR, or toggle a preview of it"},
+			{"y, Y, a (results)", "Copy the selected cell, the selected row or all rows to the clipboard"},
+			{"Up, Down", "Recall previous queries"},
+			{"Ctrl+R", "Search history: type to filter, Ctrl+R/arrows move, Enter accepts, Esc cancels"},
+			{"Tab", "Complete keywords, tables, columns and proto names"},
+			{"Ctrl+B", "Toggle the schema browser: Enter shows an element, i inserts a query"},
+			{"Ctrl+O", "Toggle the saved queries: Enter opens one in a tab"},
+			{"Ctrl+T, Ctrl+S", "Open a new tab, save the editor to the tab's saved query"},
+			{"Ctrl+Left/Right, Alt+1-9", "Switch tabs"},
+			{"Esc, Ctrl+C", "Cancel the running query"},
 			{"Ctrl+C, q", "Quit"},
 		}
-		m.results.SetContent(buildTable(rows, []string{"Command", "Description"}, m.width))
+		m.setResults(buildTable(rows, []string{"Command", "Description"}, m.contentWidth()))
 	case ".tables":
-		rows, _ := m.db.Query("SELECT name FROM sqlite_master WHERE type='table' ORDER BY name")
-		var tables []table.Row
-		for rows.Next() {
-			var name string
-			rows.Scan(&name)
-			tables = append(tables, table.Row{name})
-		}
-		m.results.SetContent(buildTable(tables, []string{"Tables"}, m.width))
+		return m.runCommandQuery(tablesQuery)
 	case ".schema":
-		rows := []table.Row{
-			{"files", "name, package, syntax, options"},
-			{"messages", "full_name, name, file, parent_message..."},
-			{"fields", "id, name, number, message, type, type_name..."},
-			{"enums", "full_name, name, file, parent_message..."},
-			{"enum_values", "id, name, number, enum, options"},
-			{"services", "full_name, name, file, options"},
-			{"methods", "full_name, name, service, input_type..."},
-			{"extensions", "full_name, name, number, file..."},
-			{"oneofs", "id, name, message, options"},
-			{"oneof_fields", "oneof_id, field_id"},
-			{"dependencies", "file, dependency, is_public..."},
+		return m.runCommandQuery(schemaQuery)
+	case ".format", ".mode":
+		switch {
+		case arg == "":
+			m.showMessage("Status", fmt.Sprintf("Format is %s", m.format))
+		case output.Valid(arg):
+			m.format = arg
+			m.showMessage("Status", fmt.Sprintf("Format set to %s", arg))
+		default:
+			m.showMessage("Error", fmt.Sprintf("Invalid format: %s", arg))
 		}
-		m.results.SetContent(buildTable(rows, []string{"Table", "Columns"}, m.width))
-	default:
-		if strings.HasPrefix(cmd, ".format ") {
-			newFmt := strings.TrimSpace(strings.TrimPrefix(cmd, ".format "))
-			if newFmt == "table" || newFmt == "json" || newFmt == "csv" {
-				m.format = newFmt
-				rows := []table.Row{{fmt.Sprintf("Format set to %s", newFmt)}}
-				m.results.SetContent(buildTable(rows, []string{"Status"}, m.width))
-			} else {
-				rows := []table.Row{{fmt.Sprintf("Invalid format: %s", newFmt)}}
-				m.results.SetContent(buildTable(rows, []string{"Error"}, m.width))
-			}
-		} else {
-			rows := []table.Row{{fmt.Sprintf("Unknown command: %s", cmd)}}
-			m.results.SetContent(buildTable(rows, []string{"Error"}, m.width))
+	case ".read":
+		if arg == "" {
+			m.showMessage("Error", "Usage: .read <file>")
+			break
+		}
+		return m.readScript(arg)
+	case ".load":
+		if arg == "" {
+			m.showMessage("Error", "Usage: .load <path...>")
+			break
 		}
+		return m.loadProtos(strings.Fields(arg))
+	case ".reload":
+		return m.reloadProtos()
+	case ".output":
+		m.setOutput(arg)
+	case ".timer":
+		switch strings.ToLower(arg) {
+		case "on":
+			m.timer = true
+		case "off":
+			m.timer = false
+		case "":
+		default:
+			m.showMessage("Error", "Usage: .timer on|off")
+			m.recalculateLayout()
+			return m, nil
+		}
+		m.showMessage("Status", fmt.Sprintf("Timer is %s", onOff(m.timer)))
+	case ".export":
+		if arg == "" {
+			m.showMessage("Error", "Usage: .export <file>")
+			break
+		}
+		m.exportResults(arg)
+	case ".clipboard":
+		switch arg {
+		case "":
+		case output.TSV, output.JSON, output.Markdown:
+			m.clipboardFormat = arg
+		default:
+			m.showMessage("Error", "Usage: .clipboard tsv|json|markdown")
+			m.recalculateLayout()
+			return m, nil
+		}
+		m.status = fmt.Sprintf("Rows are copied as %s", m.clipboardFormat)
+	case ".save":
+		m.saveQuery(arg, m.tabs[m.activeTab].lastQuery)
+	case ".open":
+		if arg == "" {
+			m.showMessage("Error", "Usage: .open <name>")
+			break
+		}
+		m.openSavedQuery(arg)
+	case ".close":
+		m.closeTab()
+	case ".describe":
+		if arg == "" {
+			m.showMessage("Error", "Usage: .describe <table|proto name>")
+			break
+		}
+		return m.describe(arg)
+	default:
+		m.showMessage("Error", fmt.Sprintf("Unknown command: %s", cmd))
 	}
 	m.recalculateLayout()
 	return m, nil
 }
 
+// showMessage replaces the results with a one-cell table.
+func (m *Model) showMessage(header, message string) {
+	m.setResults(buildTable([]table.Row{{message}}, []string{header}, m.contentWidth()))
+}
+
 func buildTable(rows []table.Row, headers []string, terminalWidth int) string {
 	if len(rows) == 0 {
 		return "No results"
@@ -262,87 +800,3 @@ func buildTable(rows []table.Row, headers []string, terminalWidth int) string {
 
 	return t.View()
 }
-
-func executeQuery(db *sql.DB, query string) ([]table.Row, []string) {
-	rows, err := db.Query(query)
-	if err != nil {
-		return []table.Row{{fmt.Sprintf("Error: %v", err)}}, []string{"Error"}
-	}
-	defer rows.Close()
-
-	cols, err := rows.Columns()
-	if err != nil {
-		return []table.Row{{fmt.Sprintf("Error: %v", err)}}, []string{"Error"}
-	}
-
-	var results []table.Row
-	for rows.Next() {
-		values := make([]any, len(cols))
-		valuePtrs := make([]any, len(cols))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-		rows.Scan(valuePtrs...)
-		row := make([]string, len(cols))
-		for i, val := range values {
-			row[i] = fmt.Sprintf("%v", val)
-		}
-		results = append(results, row)
-	}
-	return results, cols
-}
-
-func appendToHistory(query string) error {
-	usr, err := user.Current()
-	if err != nil {
-		return err
-	}
-	path := filepath.Join(usr.HomeDir, ".pbql_history")
-
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	timestamp := time.Now().Format(time.RFC3339)
-	_, err = f.WriteString(fmt.Sprintf("# %s\n%s\n\n", timestamp, query))
-	return err
-}
-
-func loadHistory() []string {
-	usr, err := user.Current()
-	if err != nil {
-		return nil
-	}
-	path := filepath.Join(usr.HomeDir, ".pbql_history")
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil
-	}
-
-	lines := strings.Split(string(data), "\n")
-	var history []string
-	var current []string
-	for _, line := range lines {
-		if strings.HasPrefix(line, "# ") {
-			if len(current) > 0 {
-				history = append([]string{strings.Join(current, "\n")}, history...)
-				current = nil
-			}
-		} else if line == "" {
-			if len(current) > 0 {
-				history = append([]string{strings.Join(current, "\n")}, history...)
-				current = nil
-			}
-		} else {
-			current = append(current, line)
-		}
-	}
-	if len(current) > 0 {
-		history = append([]string{strings.Join(current, "\n")}, history...)
-	}
-
-	return history
-}
@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// QueriesDir is where saved queries are kept, one .sql file per query,
+// relative to the project directory.
+const QueriesDir = ".pbql/queries"
+
+// readSavedQuery reads a saved query by name.
+func readSavedQuery(dir, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name+".sql"))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// writeSavedQuery saves a query by name, creating the directory if needed.
+func writeSavedQuery(dir, name, query string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name+".sql"), []byte(query), 0o644)
+}
+
+// validQueryName reports whether a name can be used as a saved query's file
+// name.
+func validQueryName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// queryPanel is the sidebar listing the saved queries.
+type queryPanel struct {
+	dir     string
+	names   []string
+	err     error
+	cursor  int
+	offset  int
+	height  int
+	focused bool
+}
+
+// newQueryPanel lists the .sql files in dir. A missing directory is an
+// empty list.
+func newQueryPanel(dir string) *queryPanel {
+	p := &queryPanel{dir: dir}
+	p.refresh()
+	return p
+}
+
+// refresh lists the saved queries again, keeping the cursor on the same
+// name when it still exists.
+func (p *queryPanel) refresh() {
+	selected := p.selected()
+	p.names, p.err = nil, nil
+	entries, err := os.ReadDir(p.dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		p.err = err
+	}
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".sql" {
+			p.names = append(p.names, strings.TrimSuffix(e.Name(), ".sql"))
+		}
+	}
+	if i := slices.Index(p.names, selected); i >= 0 {
+		p.cursor = i
+	}
+	p.clamp()
+}
+
+func (p *queryPanel) selected() string {
+	if p.cursor < 0 || p.cursor >= len(p.names) {
+		return ""
+	}
+	return p.names[p.cursor]
+}
+
+// handleKey moves the cursor and reports whether the key was used.
+func (p *queryPanel) handleKey(key string) bool {
+	switch key {
+	case "up", "k":
+		p.cursor--
+	case "down", "j":
+		p.cursor++
+	case "pgup":
+		p.cursor -= p.height - 1
+	case "pgdown":
+		p.cursor += p.height - 1
+	case "home", "g":
+		p.cursor = 0
+	case "end", "G":
+		p.cursor = len(p.names) - 1
+	default:
+		return false
+	}
+	p.clamp()
+	return true
+}
+
+func (p *queryPanel) clamp() {
+	p.cursor = max(0, min(p.cursor, len(p.names)-1))
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.height > 1 && p.cursor >= p.offset+p.height-1 {
+		p.offset = p.cursor - p.height + 2
+	}
+}
+
+func (p *queryPanel) View() string {
+	var lines []string
+	title := "Saved queries"
+	if !p.focused {
+		title = browserInactiveStyle.Render(title + " (Ctrl+O)")
+	} else {
+		title = browserTitleStyle.Render(title)
+	}
+	lines = append(lines, title)
+
+	p.height = max(1, p.height)
+	switch {
+	case p.err != nil:
+		lines = append(lines, browserInactiveStyle.Render(pad(p.err.Error(), BrowserWidth)))
+	case len(p.names) == 0:
+		lines = append(lines, browserInactiveStyle.Render(pad(fmt.Sprintf("None yet: .save <name> adds to %s", QueriesDir), BrowserWidth)))
+	}
+	for i := p.offset; i < len(p.names) && i < p.offset+p.height-1; i++ {
+		line := pad(p.names[i], BrowserWidth)
+		if i == p.cursor && p.focused {
+			line = browserCursorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < p.height {
+		lines = append(lines, "")
+	}
+	return browserStyle.Width(BrowserWidth).Render(strings.Join(lines, "\n"))
+}
+
+// openSavedQuery opens a saved query in a tab, or switches to the tab it is
+// already open in.
+func (m *Model) openSavedQuery(name string) {
+	for i, t := range m.tabs {
+		if t.name == name {
+			m.selectTab(i)
+			m.setFocus(focusEditor)
+			return
+		}
+	}
+	query, err := readSavedQuery(m.queriesDir, name)
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to open %s: %v", name, err)
+		return
+	}
+
+	// Reuse the active tab when it is unnamed and has nothing in it but
+	// the command that got here
+	draft := strings.TrimSpace(m.input.Value())
+	if m.tabs[m.activeTab].name == "" && (draft == "" || strings.HasPrefix(draft, ".")) && m.grid == nil {
+		m.input.SetValue(query)
+	} else {
+		m.openTab(name, query)
+	}
+	m.tabs[m.activeTab].name = name
+	m.tabs[m.activeTab].saved = query
+	m.setFocus(focusEditor)
+}
+
+// saveQuery saves a query under a name, or under the name the active tab
+// was opened or last saved with, and binds the tab to it. The query is left
+// in the editor to be edited and saved again.
+func (m *Model) saveQuery(name, query string) {
+	t := &m.tabs[m.activeTab]
+	if name == "" {
+		name = t.name
+	}
+	switch {
+	case name == "":
+		m.status = "Name the query with .save <name>"
+		return
+	case !validQueryName(name):
+		m.status = fmt.Sprintf("Invalid query name: %s", name)
+		return
+	case strings.TrimSpace(query) == "":
+		m.status = "Nothing to save: run a query in this tab first"
+		return
+	}
+
+	if err := writeSavedQuery(m.queriesDir, name, query); err != nil {
+		m.status = fmt.Sprintf("Failed to save %s: %v", name, err)
+		return
+	}
+	t.name, t.saved = name, query
+	m.input.SetValue(query)
+	if m.queries != nil {
+		m.queries.refresh()
+	}
+	m.status = fmt.Sprintf("Saved %s", filepath.Join(m.queriesDir, name+".sql"))
+}
+
+// toggleQueries opens and focuses the saved queries panel, focuses it if it
+// is open but unfocused, or closes it. The list is read again when opened.
+func (m *Model) toggleQueries() {
+	switch {
+	case !m.showQueries:
+		m.showBrowser = false
+		if m.queries == nil {
+			m.queries = newQueryPanel(m.queriesDir)
+		} else {
+			m.queries.refresh()
+		}
+		m.showQueries = true
+		m.setFocus(focusQueries)
+	case m.focus != focusQueries:
+		m.setFocus(focusQueries)
+	default:
+		m.showQueries = false
+		m.setFocus(focusEditor)
+	}
+}
+
+// updateQueries handles keys while the saved queries panel has focus. Enter
+// opens the selected query in a tab.
+func (m Model) updateQueries(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "tab", "shift+tab":
+		m.setFocus(focusEditor)
+		return m, nil
+	case "enter":
+		if name := m.queries.selected(); name != "" {
+			m.openSavedQuery(name)
+			m.recalculateLayout()
+		}
+		return m, nil
+	case "r":
+		m.queries.refresh()
+		return m, nil
+	}
+	m.queries.handleKey(msg.String())
+	return m, nil
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidQueryName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"messages":     true,
+		"with spaces":  true,
+		"":             false,
+		".":            false,
+		"..":           false,
+		"dir/messages": false,
+		`dir\messages`: false,
+	} {
+		if got := validQueryName(name); got != want {
+			t.Errorf("validQueryName(%q): expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestQueryPanel(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "queries")
+
+	// A missing directory is an empty list
+	p := newQueryPanel(dir)
+	if p.err != nil || len(p.names) != 0 || p.selected() != "" {
+		t.Fatalf("Unexpected panel: %v %v", p.names, p.err)
+	}
+
+	for _, name := range []string{"b", "c"} {
+		if err := writeSavedQuery(dir, name, "SELECT '"+name+"'"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644)
+	p.refresh()
+	if !slices.Equal(p.names, []string{"b", "c"}) {
+		t.Fatalf("Unexpected names %v", p.names)
+	}
+
+	// The cursor stays on the selected name as others are added
+	p.handleKey("down")
+	writeSavedQuery(dir, "a", "SELECT 'a'")
+	p.refresh()
+	if p.selected() != "c" {
+		t.Errorf("Expected c to stay selected, got %q", p.selected())
+	}
+	p.handleKey("home")
+	if p.selected() != "a" {
+		t.Errorf("Expected a to be selected, got %q", p.selected())
+	}
+	p.handleKey("up")
+	if p.selected() != "a" {
+		t.Errorf("Expected the cursor to stop at the top, got %q", p.selected())
+	}
+
+	if query, err := readSavedQuery(dir, "b"); err != nil || query != "SELECT 'b'" {
+		t.Errorf("Unexpected saved query %q (%v)", query, err)
+	}
+}
+
+func TestSaveAndOpenQuery(t *testing.T) {
+	m := tabsModel(t.TempDir())
+
+	m.saveQuery("", "SELECT 1")
+	if m.status != "Name the query with .save <name>" {
+		t.Errorf("Unexpected status %q", m.status)
+	}
+	m.saveQuery("../up", "SELECT 1")
+	if m.status != "Invalid query name: ../up" {
+		t.Errorf("Unexpected status %q", m.status)
+	}
+
+	// Saving binds the tab, so it is saved again under the same name
+	m.saveQuery("one", "SELECT 1")
+	m.saveQuery("", "SELECT 1 + 1")
+	if query, _ := readSavedQuery(m.queriesDir, "one"); query != "SELECT 1 + 1" {
+		t.Errorf("Unexpected saved query %q", query)
+	}
+	if m.tabs[0].name != "one" || m.tabs[0].title(m.input.Value()) != "one" {
+		t.Errorf("Expected the tab to be bound to one, got %q", m.tabs[0].name)
+	}
+
+	// Opening another query uses a new tab, and opening one already open
+	// switches to its tab
+	writeSavedQuery(m.queriesDir, "two", "SELECT 2")
+	m.openSavedQuery("two")
+	if len(m.tabs) != 2 || m.activeTab != 1 || m.input.Value() != "SELECT 2" {
+		t.Errorf("Unexpected tabs: %d tabs, tab %d active with %q", len(m.tabs), m.activeTab, m.input.Value())
+	}
+	m.openSavedQuery("one")
+	if len(m.tabs) != 2 || m.activeTab != 0 {
+		t.Errorf("Expected to switch to the first tab, got %d tabs, tab %d active", len(m.tabs), m.activeTab)
+	}
+}
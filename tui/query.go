@@ -0,0 +1,304 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/connor15mcc/pbql-go/output"
+)
+
+// runningQuery is a query, or another task such as loading protos,
+// executing in the background.
+type runningQuery struct {
+	id    int
+	query string
+	// tab is the tab the query was started in, which receives its results
+	tab     int
+	started time.Time
+	cancel  context.CancelFunc
+	// label describes the task in the status line while it runs
+	label string
+	// cancelled is set once the user asked to stop the query
+	cancelled bool
+	// fromEditor is set for queries typed in the editor, which is cleared
+	// on success (unless the tab holds a saved query) and has errors
+	// highlighted in it
+	fromEditor bool
+	input      string
+	// user is set for SQL written by the user, in the editor or a script
+	// run by .read, which is checked in read-only mode and can change the
+	// tables
+	user bool
+	// doneStatus is shown in the status line when the query succeeds
+	doneStatus string
+	// reloads marks tasks that change the loaded protos, after which the
+	// completer and schema browser are rebuilt
+	reloads bool
+}
+
+// queryResultMsg is the outcome of a query started by startQuery or a task
+// started by startTask. A result with only a status leaves the results pane
+// untouched.
+type queryResultMsg struct {
+	id      int
+	cols    []string
+	values  [][]any
+	content string
+	status  string
+	err     error
+}
+
+// startQuery runs a query in the background, returning the command that
+// executes it and starts the spinner. The query is interrupted through its
+// context when cancelQuery is called. While .output is set, the results are
+// written to the output file instead of the results pane. fromEditor and
+// user set the runningQuery fields of the same name.
+func (m *Model) startQuery(query string, fromEditor, user bool) tea.Cmd {
+	db, schemaDB, format, out := m.db, m.schema, m.format, m.output
+	cmd := m.startTask("Running query", func(ctx context.Context) queryResultMsg {
+		if user {
+			if err := schemaDB.CheckQuery(ctx, query); err != nil {
+				return queryResultMsg{err: err}
+			}
+		}
+		if out == nil {
+			return fetchQuery(ctx, db, query, format)
+		}
+		if err := writeQuery(ctx, out, db, query, format); err != nil {
+			return queryResultMsg{err: err}
+		}
+		return queryResultMsg{status: fmt.Sprintf("Results written to %s", out.Name())}
+	})
+	m.running.query = query
+	m.running.fromEditor = fromEditor
+	m.running.user = user
+	return cmd
+}
+
+// startTask runs fn in the background like a query: with a spinner, and
+// cancellable through its context.
+func (m *Model) startTask(label string, fn func(ctx context.Context) queryResultMsg) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.queryID++
+	m.running = &runningQuery{
+		id:      m.queryID,
+		label:   label,
+		started: time.Now(),
+		cancel:  cancel,
+		input:   m.input.Value(),
+		tab:     m.activeTab,
+	}
+	m.recalculateLayout()
+
+	id := m.queryID
+	run := func() tea.Msg {
+		defer cancel()
+		msg := fn(ctx)
+		msg.id = id
+		return msg
+	}
+	return tea.Batch(run, m.spinner.Tick)
+}
+
+// cancelQuery interrupts the running query. Its result still arrives as a
+// queryResultMsg, reporting the cancellation.
+func (m *Model) cancelQuery() {
+	if m.running == nil {
+		return
+	}
+	m.running.cancelled = true
+	m.running.cancel()
+}
+
+// handleQueryResult shows the results of a finished query in the tab it was
+// started in. On error the previous results are left untouched. After
+// reloads and statements that can change the tables, it returns the command
+// rebuilding the completer.
+func (m *Model) handleQueryResult(msg queryResultMsg) tea.Cmd {
+	running := m.running
+	if running == nil || msg.id != running.id {
+		return nil
+	}
+	m.running = nil
+	defer m.recalculateLayout()
+	m.inTab(running.tab, func() { m.applyQueryResult(running, msg) })
+
+	if running.reloads || (running.user && changesSchema(running.query)) {
+		return loadCompleter(m.db)
+	}
+	return nil
+}
+
+// schemaKeywords start the statements that can create or drop tables and
+// views.
+var schemaKeywords = map[string]bool{
+	"CREATE": true, "DROP": true, "ALTER": true, "ATTACH": true,
+	"DETACH": true, "IMPORT": true, "USE": true,
+}
+
+// changesSchema reports whether any statement of a script can change the
+// tables and views, judged by its first keyword.
+func changesSchema(script string) bool {
+	for _, stmt := range output.Split(script) {
+		word := stmt.SQL
+		if end := strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+			word = word[:end]
+		}
+		if schemaKeywords[strings.ToUpper(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) applyQueryResult(running *runningQuery, msg queryResultMsg) {
+	if running.fromEditor {
+		m.tabs[m.activeTab].lastQuery = running.query
+	}
+	if running.fromEditor && (msg.err == nil || !m.skipFailed) {
+		if err := m.history.add(running.query); err != nil {
+			m.status = fmt.Sprintf("Could not save history: %v", err)
+		}
+	}
+
+	if msg.err != nil {
+		switch {
+		case running.cancelled:
+			m.status = fmt.Sprintf("Cancelled after %s", time.Since(running.started).Truncate(time.Millisecond))
+		case running.fromEditor:
+			// Keep the query so it can be fixed. Offsets are relative to the
+			// trimmed query (or the failing statement of a script), so shift
+			// them onto the editor contents.
+			var scriptErr *output.ScriptError
+			shift := strings.Index(running.input, running.query)
+			if errors.As(msg.err, &scriptErr) {
+				m.queryErr = newQueryError(scriptErr.Statement.SQL, scriptErr.Err)
+				m.queryErr.message = fmt.Sprintf("statement %d: %s", scriptErr.Index+1, m.queryErr.message)
+				shift += scriptErr.Statement.Offset
+			} else {
+				m.queryErr = newQueryError(running.query, msg.err)
+			}
+			m.queryErr.query = running.input
+			if m.queryErr.offset >= 0 {
+				m.queryErr.offset += shift
+			}
+		default:
+			m.status = msg.err.Error()
+		}
+		return
+	}
+
+	switch {
+	case msg.cols != nil && msg.content == "":
+		m.grid = newGrid(msg.cols, msg.values)
+		m.grid.focused = m.focus == focusResults
+		m.refreshPreview()
+	case msg.status == "":
+		m.grid = nil
+		m.results.SetContent(msg.content)
+	}
+	if msg.status == "" {
+		m.resultCols, m.resultValues = msg.cols, msg.values
+	}
+
+	var status []string
+	for _, s := range []string{msg.status, running.doneStatus} {
+		if s != "" {
+			status = append(status, s)
+		}
+	}
+	if m.timer {
+		status = append(status, fmt.Sprintf("Run time: %s", time.Since(running.started).Truncate(time.Millisecond)))
+	}
+	if len(status) > 0 {
+		m.status = strings.Join(status, "  ")
+	}
+
+	if running.reloads {
+		m.browser = nil
+		if m.showBrowser {
+			m.showBrowser = false
+			m.toggleBrowser()
+		}
+	}
+
+	if running.fromEditor {
+		m.queryErr = nil
+		// Saved queries stay in the editor, to be edited and saved again
+		if m.input.Value() == running.input && m.tabs[m.activeTab].name == "" {
+			m.input.Reset()
+		}
+	}
+}
+
+// writeQuery executes a query, or each statement of a script, writing the
+// results to w in the given format.
+func writeQuery(ctx context.Context, w io.Writer, db *sql.DB, query, format string) error {
+	if len(output.Split(query)) != 1 {
+		return output.Script(ctx, w, db, query, format)
+	}
+	return output.Query(ctx, w, db, query, format)
+}
+
+// fetchQuery executes a query and collects its results in the given format.
+// Tables are returned as values for the navigable grid; JSON, CSV and
+// multi-statement scripts go through the same formatter as the
+// non-interactive CLI, with JSON syntax-colored. The values of a single
+// statement are returned in every format, to be copied or exported.
+func fetchQuery(ctx context.Context, db *sql.DB, query, format string) queryResultMsg {
+	var buf bytes.Buffer
+	var msg queryResultMsg
+	if len(output.Split(query)) == 1 {
+		cols, values, err := executeQuery(ctx, db, query)
+		if err != nil || (format != output.JSON && format != output.CSV) {
+			return queryResultMsg{cols: cols, values: values, err: err}
+		}
+		msg.cols, msg.values = cols, values
+		if err := output.Values(&buf, cols, values, format); err != nil {
+			return queryResultMsg{err: err}
+		}
+	} else if err := writeQuery(ctx, &buf, db, query, format); err != nil {
+		return queryResultMsg{err: err}
+	}
+
+	msg.content = buf.String()
+	if format == output.JSON {
+		msg.content = highlightJSON(msg.content)
+	}
+	return msg
+}
+
+func executeQuery(ctx context.Context, db *sql.DB, query string) ([]string, [][]any, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := [][]any{}
+	for rows.Next() {
+		values := make([]any, len(cols))
+		valuePtrs := make([]any, len(cols))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, nil, err
+		}
+		results = append(results, values)
+	}
+	return cols, results, rows.Err()
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/output"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+func TestChangesSchema(t *testing.T) {
+	for script, want := range map[string]bool{
+		"SELECT * FROM messages":               false,
+		"create view v AS SELECT 1":            true,
+		"SELECT 1; DROP VIEW v":                true,
+		"-- comment\nCREATE TABLE t (x INT)":   true,
+		"SELECT 'CREATE TABLE t' AS s":         false,
+		"INSERT INTO t VALUES (1)":             false,
+		"ATTACH 'other.db' AS other":           true,
+		"WITH x AS (SELECT 1) SELECT * FROM x": false,
+	} {
+		if got := changesSchema(script); got != want {
+			t.Errorf("changesSchema(%q) = %v, want %v", script, got, want)
+		}
+	}
+}
+
+func TestFetchQueryFormats(t *testing.T) {
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// Tables are shown in the grid, without rendered content
+	msg := fetchQuery(ctx, db.DB, "SELECT 1 AS n, 'a,b' AS s", output.Table)
+	if msg.err != nil || msg.content != "" || !slices.Equal(msg.cols, []string{"n", "s"}) || len(msg.values) != 1 {
+		t.Errorf("Unexpected table result: %+v", msg)
+	}
+
+	// JSON and CSV are rendered, keeping the rows to copy them
+	msg = fetchQuery(ctx, db.DB, "SELECT 1 AS n, 'a,b' AS s", output.CSV)
+	if msg.err != nil || msg.content != "n,s\n1,\"a,b\"\n" || len(msg.values) != 1 {
+		t.Errorf("Unexpected CSV result: %+v", msg)
+	}
+	msg = fetchQuery(ctx, db.DB, "SELECT 1 AS n, 'a,b' AS s", output.JSON)
+	if content := regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(msg.content, ""); msg.err != nil || !strings.Contains(content, `"n": 1`) || !strings.Contains(content, `"s": "a,b"`) {
+		t.Errorf("Unexpected JSON result: %+v", msg)
+	}
+
+	// Each statement of a script is rendered
+	msg = fetchQuery(ctx, db.DB, "SELECT 1 AS a; SELECT 2 AS b", output.CSV)
+	if msg.err != nil || !strings.Contains(msg.content, "a\n1\n") || !strings.Contains(msg.content, "b\n2\n") {
+		t.Errorf("Unexpected script result: %+v", msg)
+	}
+}
@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	searchPromptStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#00FF00"))
+	searchMatchStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFAF5F"))
+)
+
+// historySearch is the Ctrl+R overlay listing the history entries that
+// fuzzily match a search term, best match first.
+type historySearch struct {
+	history *history
+	term    string
+	// matches are indexes into history, with the positions of the matched
+	// runes for highlighting
+	matches []searchMatch
+	cursor  int
+	offset  int
+	width   int
+	height  int
+}
+
+type searchMatch struct {
+	index     int
+	positions []int
+}
+
+func newHistorySearch(h *history) *historySearch {
+	s := &historySearch{history: h}
+	s.refresh()
+	return s
+}
+
+// refresh recomputes the matches for the current term. Entries containing
+// the term as a substring rank before those only matching as a subsequence;
+// within each group the most recent entries come first.
+func (s *historySearch) refresh() {
+	var exact, fuzzy []searchMatch
+	for i := range s.history.len() {
+		query := s.history.at(i)
+		if positions, ok := substringMatch(query, s.term); ok {
+			exact = append(exact, searchMatch{i, positions})
+		} else if positions, ok := fuzzyMatch(query, s.term); ok {
+			fuzzy = append(fuzzy, searchMatch{i, positions})
+		}
+	}
+	s.matches = append(exact, fuzzy...)
+	s.cursor = 0
+	s.offset = 0
+}
+
+// substringMatch reports the rune positions of the first case-insensitive
+// occurrence of term in text.
+func substringMatch(text, term string) ([]int, bool) {
+	if term == "" {
+		return nil, true
+	}
+	i := strings.Index(strings.ToLower(text), strings.ToLower(term))
+	if i < 0 {
+		return nil, false
+	}
+	start := utf8.RuneCountInString(strings.ToLower(text)[:i])
+	positions := make([]int, utf8.RuneCountInString(term))
+	for j := range positions {
+		positions[j] = start + j
+	}
+	return positions, true
+}
+
+// fuzzyMatch reports the rune positions of term's runes appearing in order,
+// case-insensitively, in text.
+func fuzzyMatch(text, term string) ([]int, bool) {
+	want := []rune(strings.ToLower(term))
+	var positions []int
+	for i, r := range []rune(text) {
+		if len(positions) == len(want) {
+			break
+		}
+		if unicode.ToLower(r) == want[len(positions)] {
+			positions = append(positions, i)
+		}
+	}
+	return positions, len(positions) == len(want)
+}
+
+// selected returns the highlighted query, if any.
+func (s *historySearch) selected() (string, bool) {
+	if s.cursor >= len(s.matches) {
+		return "", false
+	}
+	return s.history.at(s.matches[s.cursor].index), true
+}
+
+// handleKey applies an editing or navigation key and reports whether it was
+// used. Ctrl+R, like in a shell, moves to the next (older) match.
+func (s *historySearch) handleKey(key string, runes []rune) bool {
+	switch key {
+	case "ctrl+r", "up", "ctrl+p":
+		s.cursor++
+	case "down", "ctrl+n":
+		s.cursor--
+	case "pgup":
+		s.cursor -= s.pageSize()
+	case "pgdown":
+		s.cursor += s.pageSize()
+	case "backspace", "ctrl+h":
+		if s.term == "" {
+			return true
+		}
+		_, size := utf8.DecodeLastRuneInString(s.term)
+		s.term = s.term[:len(s.term)-size]
+		s.refresh()
+	case "ctrl+u":
+		s.term = ""
+		s.refresh()
+	default:
+		if len(runes) == 0 {
+			return false
+		}
+		s.term += string(runes)
+		s.refresh()
+	}
+	s.clamp()
+	return true
+}
+
+// pageSize is the number of matches shown below the prompt.
+func (s *historySearch) pageSize() int {
+	return max(1, s.height-1)
+}
+
+func (s *historySearch) clamp() {
+	s.cursor = max(0, min(s.cursor, len(s.matches)-1))
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+s.pageSize() {
+		s.offset = s.cursor - s.pageSize() + 1
+	}
+}
+
+func (s *historySearch) setSize(width, height int) {
+	s.width = width
+	s.height = height
+	s.clamp()
+}
+
+// View renders the prompt followed by the matches, one line each with
+// newlines folded, padded to the height of the results pane.
+func (s *historySearch) View() string {
+	prompt := fmt.Sprintf("History search (%d/%d): ", len(s.matches), s.history.len())
+	lines := []string{searchPromptStyle.Render(prompt) + s.term + cursorStyle.Render(" ")}
+
+	for i := s.offset; i < len(s.matches) && i < s.offset+s.pageSize(); i++ {
+		match := s.matches[i]
+		line := s.renderMatch(s.history.at(match.index), match.positions)
+		if i == s.cursor {
+			line = browserCursorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(s.matches) == 0 {
+		lines = append(lines, statusStyle.Render("No matching queries"))
+	}
+	for len(lines) < s.height {
+		lines = append(lines, "")
+	}
+	return lipgloss.NewStyle().MaxWidth(s.width).Render(strings.Join(lines, "\n"))
+}
+
+// renderMatch folds a query onto one line, truncated to the pane width, with
+// the matched runes highlighted.
+func (s *historySearch) renderMatch(query string, positions []int) string {
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var b strings.Builder
+	width := 0
+	for i, r := range []rune(query) {
+		if s.width > 0 && width >= s.width-1 {
+			b.WriteString("…")
+			break
+		}
+		if r == '\n' || r == '\t' {
+			r = ' '
+		}
+		if matched[i] {
+			b.WriteString(searchMatchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+		width++
+	}
+	return b.String()
+}
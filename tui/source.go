@@ -0,0 +1,197 @@
+package tui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/protocompile/linker"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var previewBorderStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderTop(true)
+
+// sourceColumns name proto elements, most specific first. They are tried
+// before the other columns when locating the source of a row.
+var sourceColumns = []string{"full_name", "id", "field_id", "oneof_id", "method", "message", "enum", "service", "extendee"}
+
+// fileColumns name proto files. Rows without a known element open the file
+// at its first line.
+var fileColumns = []string{"file", "name", "dependency"}
+
+// sourceLocation is where a proto element is defined.
+type sourceLocation struct {
+	// file is the proto path relative to its import root, and path the
+	// file on disk
+	file string
+	path string
+	line int // 1-based
+}
+
+func (l sourceLocation) String() string {
+	return fmt.Sprintf("%s:%d", l.file, l.line)
+}
+
+// locateRow finds the definition of the proto element in the selected
+// result row.
+func (m Model) locateRow() (sourceLocation, error) {
+	if m.grid == nil || len(m.grid.values) == 0 {
+		return sourceLocation{}, errors.New("no row selected")
+	}
+	row := m.grid.values[m.grid.cursorRow]
+	value := func(col int) string {
+		s, _ := row[col].(string)
+		return s
+	}
+
+	var cols []int
+	for _, name := range sourceColumns {
+		if i := slices.Index(m.grid.columns, name); i >= 0 {
+			cols = append(cols, i)
+		}
+	}
+	for i := range m.grid.columns {
+		if !slices.Contains(cols, i) {
+			cols = append(cols, i)
+		}
+	}
+
+	for _, col := range cols {
+		name := value(col)
+		if name == "" {
+			continue
+		}
+		if d := m.schema.Descriptor(name); d != nil {
+			file, line := descriptorLocation(d)
+			return m.sourceLocation(file, line)
+		}
+	}
+
+	for _, name := range fileColumns {
+		i := slices.Index(m.grid.columns, name)
+		if i < 0 {
+			continue
+		}
+		file := value(i)
+		if slices.ContainsFunc(m.schema.Files(), func(f linker.File) bool { return f.Path() == file }) {
+			return m.sourceLocation(file, 1)
+		}
+	}
+	return sourceLocation{}, errors.New("no proto element in the selected row")
+}
+
+// descriptorLocation returns the file and line defining d. Elements without
+// a location of their own, such as map entries, use their parent's.
+func descriptorLocation(d protoreflect.Descriptor) (string, int) {
+	file := d.ParentFile()
+	for ; d != nil; d = d.Parent() {
+		if _, ok := d.(protoreflect.FileDescriptor); ok {
+			break
+		}
+		if loc := file.SourceLocations().ByDescriptor(d); len(loc.Path) > 0 {
+			return file.Path(), loc.StartLine + 1
+		}
+	}
+	return file.Path(), 1
+}
+
+// sourceLocation finds a proto file on disk, under the inputs it may have
+// been loaded from.
+func (m Model) sourceLocation(file string, line int) (sourceLocation, error) {
+	for _, root := range slices.Concat(m.roots, m.loaded) {
+		info, err := os.Stat(root)
+		if err != nil {
+			continue
+		}
+		dir := root
+		if !info.IsDir() {
+			dir = filepath.Dir(root)
+		}
+		path := filepath.Join(dir, file)
+		if _, err := os.Stat(path); err == nil {
+			return sourceLocation{file: file, path: path, line: line}, nil
+		}
+	}
+	return sourceLocation{}, fmt.Errorf("%s is not in the loaded inputs", file)
+}
+
+// editorFinishedMsg reports that the editor started by openEditor exited.
+type editorFinishedMsg struct {
+	err error
+}
+
+// openEditor suspends the program to open $VISUAL or $EDITOR (vi by
+// default) at a source location.
+func openEditor(loc sourceLocation) tea.Cmd {
+	args := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	args = append(args, fmt.Sprintf("+%d", loc.line), loc.path)
+	cmd := exec.Command(args[0], args[1:]...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
+
+// sourcePreview is the source around the selected row's element, shown
+// below the results grid.
+type sourcePreview struct {
+	loc   sourceLocation
+	lines []string
+	err   error
+}
+
+// loadPreview locates the selected row and reads its file. The lines of
+// prev are reused when the file is unchanged.
+func (m Model) loadPreview(prev *sourcePreview) *sourcePreview {
+	loc, err := m.locateRow()
+	if err != nil {
+		return &sourcePreview{err: err}
+	}
+	if prev != nil && prev.err == nil && prev.loc.path == loc.path {
+		return &sourcePreview{loc: loc, lines: prev.lines}
+	}
+	data, err := os.ReadFile(loc.path)
+	if err != nil {
+		return &sourcePreview{err: err}
+	}
+	text := strings.ReplaceAll(string(data), "\t", "    ")
+	return &sourcePreview{loc: loc, lines: strings.Split(text, "\n")}
+}
+
+// refreshPreview follows the selected row, while the preview is open.
+func (m *Model) refreshPreview() {
+	if m.preview != nil {
+		m.preview = m.loadPreview(m.preview)
+	}
+}
+
+// View renders the preview in height lines: a border, the location and the
+// source with the element's line highlighted about a third of the way down.
+func (p *sourcePreview) View(width, height int) string {
+	var b strings.Builder
+	if p.err != nil {
+		b.WriteString(statusStyle.Render(fmt.Sprintf("No source: %v", p.err)))
+	} else {
+		b.WriteString(detailNameStyle.Render(p.loc.String()))
+		rows := max(0, height-2)
+		start := max(0, min(p.loc.line-1-rows/3, len(p.lines)-rows))
+		end := min(len(p.lines), start+rows)
+		numWidth := len(strconv.Itoa(end))
+		for i := start; i < end; i++ {
+			line := pad(fmt.Sprintf("%*d  %s", numWidth, i+1, p.lines[i]), width)
+			if i == p.loc.line-1 {
+				line = gridCursorRowStyle.Render(line)
+			}
+			b.WriteString("\n" + line)
+		}
+	}
+	content := lipgloss.NewStyle().MaxWidth(width).Height(max(1, height-1)).Render(b.String())
+	return previewBorderStyle.Width(width).Render(content)
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/connor15mcc/pbql-go/parser"
+	"github.com/connor15mcc/pbql-go/schema"
+)
+
+// lineOf returns the 1-based line of path starting with prefix.
+func lineOf(t *testing.T, path, prefix string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	i := slices.IndexFunc(lines, func(l string) bool { return strings.HasPrefix(strings.TrimSpace(l), prefix) })
+	if i < 0 {
+		t.Fatalf("No line starting with %q in %s", prefix, path)
+	}
+	return i + 1
+}
+
+func TestLocateRow(t *testing.T) {
+	db, err := schema.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+	result, err := parser.ParsePaths(context.Background(), []string{"../testdata"}, parser.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.LoadFiles(result.Files); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m := initialModel(db, Options{Roots: []string{"../testdata"}})
+	path := filepath.Join("..", "testdata", "users.proto")
+
+	for _, tt := range []struct {
+		cols   []string
+		row    []any
+		prefix string
+	}{
+		// Element columns are preferred over the others, wherever they are
+		{[]string{"name", "file", "full_name"}, []any{"User", "users.proto", "example.users.User"}, "message User {"},
+		{[]string{"id", "message"}, []any{"example.users.User.email", "example.users.User"}, "string email = 2;"},
+		{[]string{"full_name"}, []any{"example.users.UserStatus"}, "enum UserStatus {"},
+		// Without an element, the file opens at its first line
+		{[]string{"name", "package"}, []any{"users.proto", "example.users"}, ""},
+	} {
+		m.grid = newGrid(tt.cols, [][]any{tt.row})
+		loc, err := m.locateRow()
+		if err != nil {
+			t.Errorf("Locating %v: unexpected error: %v", tt.row, err)
+			continue
+		}
+		want := 1
+		if tt.prefix != "" {
+			want = lineOf(t, path, tt.prefix)
+		}
+		if loc.file != "users.proto" || loc.path != path || loc.line != want {
+			t.Errorf("Locating %v: expected %s:%d, got %+v", tt.row, path, want, loc)
+		}
+	}
+
+	m.grid = newGrid([]string{"n"}, [][]any{{int64(1)}})
+	if _, err := m.locateRow(); err == nil {
+		t.Errorf("Expected an error for a row without proto elements")
+	}
+
+	// The preview shows the location
+	m.grid = newGrid([]string{"full_name"}, [][]any{{"example.users.User"}})
+	want := "users.proto:" + strconv.Itoa(lineOf(t, path, "message User {"))
+	if view := m.loadPreview(nil).View(80, 10); !strings.Contains(view, want) {
+		t.Errorf("Expected the preview to show %s, got:\n%s", want, view)
+	}
+}
@@ -0,0 +1,247 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	tabStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	activeTabStyle = lipgloss.NewStyle().Bold(true).Reverse(true)
+)
+
+// tab is an editor with its own results. The state of the active tab lives
+// in the Model's fields and is copied here when another tab is selected.
+type tab struct {
+	// name is the saved query the tab was opened from or saved as, and
+	// saved its text at that point, to mark unsaved changes
+	name  string
+	saved string
+	// lastQuery is the last query run from the tab's editor, saved by .save
+	lastQuery string
+
+	input        textarea.Model
+	results      viewport.Model
+	grid         *grid
+	preview      *sourcePreview
+	resultCols   []string
+	resultValues [][]any
+	queryErr     *queryError
+	historyPos   int
+}
+
+func newTab(name, query string) tab {
+	input := newEditor()
+	input.SetValue(query)
+	input.Blur()
+	return tab{
+		name:       name,
+		input:      input,
+		results:    viewport.New(DefaultTerminalWidth, 0),
+		historyPos: -1,
+	}
+}
+
+// title is the tab's label in the tab bar.
+func (t tab) title(query string) string {
+	if t.name == "" {
+		return "untitled"
+	}
+	if query != t.saved {
+		return t.name + "*"
+	}
+	return t.name
+}
+
+// stashTab copies the active tab's state out of the model.
+func (m *Model) stashTab() {
+	t := &m.tabs[m.activeTab]
+	t.input, t.results, t.grid, t.preview = m.input, m.results, m.grid, m.preview
+	t.resultCols, t.resultValues = m.resultCols, m.resultValues
+	t.queryErr, t.historyPos = m.queryErr, m.historyPos
+}
+
+// restoreTab makes tab i the active tab, copying its state into the model.
+func (m *Model) restoreTab(i int) {
+	m.activeTab = i
+	t := m.tabs[i]
+	m.input, m.results, m.grid, m.preview = t.input, t.results, t.grid, t.preview
+	m.resultCols, m.resultValues = t.resultCols, t.resultValues
+	m.queryErr, m.historyPos = t.queryErr, t.historyPos
+	if m.focus == focusResults && m.grid == nil {
+		m.focus = focusEditor
+	}
+	m.setFocus(m.focus)
+}
+
+// selectTab switches to tab i, if it exists.
+func (m *Model) selectTab(i int) {
+	if i < 0 || i >= len(m.tabs) || i == m.activeTab {
+		return
+	}
+	m.stashTab()
+	m.restoreTab(i)
+	m.recalculateLayout()
+}
+
+// openTab adds a tab after the existing ones and switches to it.
+func (m *Model) openTab(name, query string) {
+	m.stashTab()
+	m.tabs = append(m.tabs, newTab(name, query))
+	m.restoreTab(len(m.tabs) - 1)
+	m.setFocus(focusEditor)
+	m.recalculateLayout()
+}
+
+// closeTab closes the active tab. The last tab is cleared instead.
+func (m *Model) closeTab() {
+	if m.running != nil && m.running.tab == m.activeTab {
+		m.status = "The query in this tab is running (Esc to cancel)"
+		return
+	}
+	if len(m.tabs) == 1 {
+		m.tabs[0] = newTab("", "")
+		m.restoreTab(0)
+		m.setFocus(focusEditor)
+		m.recalculateLayout()
+		return
+	}
+
+	closed := m.activeTab
+	m.tabs = append(m.tabs[:closed], m.tabs[closed+1:]...)
+	if m.running != nil && m.running.tab > closed {
+		m.running.tab--
+	}
+	m.restoreTab(min(closed, len(m.tabs)-1))
+	m.recalculateLayout()
+}
+
+// inTab runs fn with tab i active, then switches back. Results of a query
+// started in a tab that is no longer active are delivered through it.
+func (m *Model) inTab(i int, fn func()) {
+	if i == m.activeTab || i >= len(m.tabs) {
+		fn()
+		return
+	}
+	active, focus := m.activeTab, m.focus
+	m.stashTab()
+	m.restoreTab(i)
+	fn()
+	m.stashTab()
+	m.focus = focus
+	m.restoreTab(active)
+}
+
+// tabBar renders the tab titles, numbered for Alt+1-9. It is only shown
+// once there is more than one tab.
+func (m Model) tabBar() string {
+	if len(m.tabs) < 2 {
+		return ""
+	}
+	var titles []string
+	for i, t := range m.tabs {
+		query := t.input.Value()
+		if i == m.activeTab {
+			query = m.input.Value()
+		}
+		title := fmt.Sprintf(" %d %s ", i+1, t.title(query))
+		if i == m.activeTab {
+			titles = append(titles, activeTabStyle.Render(title))
+		} else {
+			titles = append(titles, tabStyle.Render(title))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(m.contentWidth()).Render(strings.Join(titles, " "))
+}
+
+// TabsPath returns the file recording a project's open tabs, keyed by its
+// input roots like the history.
+func TabsPath(roots []string) (string, error) {
+	path, err := projectPath("tabs", roots)
+	if err != nil {
+		return "", err
+	}
+	return path + ".json", nil
+}
+
+// savedTabs is the tabs file: the text of each tab, the saved query it
+// belongs to and which tab is active.
+type savedTabs struct {
+	Active int        `json:"active"`
+	Tabs   []savedTab `json:"tabs"`
+}
+
+type savedTab struct {
+	Name  string `json:"name,omitempty"`
+	Query string `json:"query"`
+}
+
+// loadTabs restores the tabs recorded at path, or returns a single empty
+// tab. Tabs of saved queries are compared against the query files, so
+// changes made outside the editor show as unsaved.
+func loadTabs(path, queriesDir string) ([]tab, int) {
+	var saved savedTabs
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &saved)
+	}
+	if len(saved.Tabs) == 0 {
+		return []tab{newTab("", "")}, 0
+	}
+
+	tabs := make([]tab, len(saved.Tabs))
+	for i, st := range saved.Tabs {
+		tabs[i] = newTab(st.Name, st.Query)
+		if st.Name != "" {
+			tabs[i].saved, _ = readSavedQuery(queriesDir, st.Name)
+		}
+	}
+	return tabs, max(0, min(saved.Active, len(tabs)-1))
+}
+
+// saveTabs records the open tabs at the path they were loaded from.
+func (m Model) saveTabs() error {
+	if m.tabsFile == "" {
+		return nil
+	}
+	m.stashTab()
+	saved := savedTabs{Active: m.activeTab}
+	for _, t := range m.tabs {
+		saved.Tabs = append(saved.Tabs, savedTab{Name: t.name, Query: t.input.Value()})
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.tabsFile), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(m.tabsFile, data, 0o644)
+}
+
+// handleTabKey opens and switches tabs, and saves the active one, in any
+// pane. It reports whether the key was used.
+func (m *Model) handleTabKey(key string) bool {
+	switch key {
+	case "ctrl+t":
+		m.openTab("", "")
+	case "ctrl+s":
+		m.saveQuery("", m.input.Value())
+	case "ctrl+right", "ctrl+pgdown":
+		m.selectTab((m.activeTab + 1) % len(m.tabs))
+	case "ctrl+left", "ctrl+pgup":
+		m.selectTab((m.activeTab + len(m.tabs) - 1) % len(m.tabs))
+	case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+		m.selectTab(int(key[len(key)-1] - '1'))
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tabsModel returns a model with the tabs loaded from dir, as Run sets it up.
+func tabsModel(dir string) *Model {
+	m := &Model{
+		tabsFile:   filepath.Join(dir, "tabs.json"),
+		queriesDir: filepath.Join(dir, "queries"),
+	}
+	m.tabs, m.activeTab = loadTabs(m.tabsFile, m.queriesDir)
+	m.restoreTab(m.activeTab)
+	return m
+}
+
+func TestTabsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	m := tabsModel(dir)
+	if len(m.tabs) != 1 || m.tabs[0].name != "" {
+		t.Fatalf("Expected a single untitled tab, got %d tabs", len(m.tabs))
+	}
+
+	m.input.SetValue("SELECT 1")
+	if err := writeSavedQuery(m.queriesDir, "messages", "SELECT * FROM messages"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.openTab("messages", "SELECT * FROM messages LIMIT 1")
+	m.tabs[m.activeTab].saved = "SELECT * FROM messages LIMIT 1"
+	m.openTab("", "SELECT 3")
+	m.selectTab(1)
+	if err := m.saveTabs(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	m = tabsModel(dir)
+	if len(m.tabs) != 3 || m.activeTab != 1 {
+		t.Fatalf("Unexpected tabs: %d tabs, tab %d active", len(m.tabs), m.activeTab)
+	}
+	for i, want := range []string{"SELECT 1", "SELECT * FROM messages LIMIT 1", "SELECT 3"} {
+		if got := m.tabs[i].input.Value(); i != m.activeTab && got != want {
+			t.Errorf("Tab %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if got := m.input.Value(); got != "SELECT * FROM messages LIMIT 1" {
+		t.Errorf("Unexpected active tab query %q", got)
+	}
+
+	// The saved query tab is compared against the file, which differs
+	if got := m.tabs[1].title(m.input.Value()); got != "messages*" {
+		t.Errorf("Unexpected title %q", got)
+	}
+	if got := m.tabs[0].title(m.tabs[0].input.Value()); got != "untitled" {
+		t.Errorf("Unexpected title %q", got)
+	}
+}
+
+func TestTabsClose(t *testing.T) {
+	m := tabsModel(t.TempDir())
+	m.input.SetValue("SELECT 1")
+	m.openTab("", "SELECT 2")
+	m.openTab("", "SELECT 3")
+	m.selectTab(1)
+
+	m.closeTab()
+	if len(m.tabs) != 2 || m.activeTab != 1 || m.input.Value() != "SELECT 3" {
+		t.Errorf("Unexpected tabs after closing the middle one: %d tabs, tab %d active with %q",
+			len(m.tabs), m.activeTab, m.input.Value())
+	}
+	m.closeTab()
+	m.closeTab()
+	if len(m.tabs) != 1 || m.input.Value() != "" {
+		t.Errorf("Expected the last tab to be cleared, got %d tabs with %q", len(m.tabs), m.input.Value())
+	}
+}
+
+func TestLoadTabsCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tabs.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tabs, active := loadTabs(path, ""); len(tabs) != 1 || active != 0 {
+		t.Errorf("Expected a single empty tab, got %d tabs, tab %d active", len(tabs), active)
+	}
+}